@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lcrownover/process-job-stats-go/internal/daemon"
+)
+
+// runDaemon processes every day from backfillFrom through yesterday that the
+// state file doesn't have yet, then sleeps until delay past the next midnight
+// and repeats. Failed days are retried after retry, and days after one that
+// had too many failed jobs are still processed.
+func runDaemon(r *runner, statePath, backfillFrom string, delay, retry time.Duration) {
+	// each cycle reopens the outputs, so a single file would only ever hold
+	// the last batch of days
+	for flagName, path := range map[string]string{
+		"output":           r.opts.output,
+		"steps-output":     r.opts.stepsOutput,
+		"summary-output":   r.opts.summaryOutput,
+		"gpu-hours-output": r.opts.gpuHoursOutput,
+	} {
+		if path != "" && !strings.Contains(path, "{{") {
+			log.Fatalf("-daemon needs -%s to be a per-day template like out/{{.Date}}.csv", flagName)
+		}
+	}
+	state, err := daemon.LoadState(statePath)
+	if err != nil {
+		log.Fatal("Failed to load daemon state: ", err)
+	}
+	if backfillFrom != "" {
+		if _, err := time.Parse("2006-01-02", backfillFrom); err != nil {
+			log.Fatal("Failed to parse backfill day: ", backfillFrom)
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Starting daemon, state file: %s", statePath))
+	for {
+		through := daemon.LastReadyDay(time.Now(), delay)
+		from := backfillFrom
+		if from == "" {
+			from = state.Earliest()
+		}
+		if from == "" || from > through {
+			from = through
+		}
+
+		wait := time.Until(daemon.NextRun(time.Now(), delay))
+		pending, err := state.Pending(from, through)
+		if err != nil {
+			log.Fatal("Failed to find pending days: ", err)
+		}
+		if len(pending) > 0 {
+			slog.Info(fmt.Sprintf("Processing %d pending days: %s -> %s", len(pending), pending[0], pending[len(pending)-1]))
+			// a day with too many failed jobs stays pending, but shouldn't
+			// hold back the days after it
+			done, err := r.runDays(pending, true)
+			if merr := state.Mark(done...); merr != nil {
+				slog.Error(fmt.Sprintf("Failed to save daemon state: %v", merr))
+			}
+			if err != nil {
+				slog.Error(fmt.Sprintf("Failed to process jobs, retrying in %s: %v", retry, err))
+				wait = min(wait, retry)
+			}
+			slog.Info(fmt.Sprintf("Processed %d days: %d processed, %d skipped, %d failed jobs so far",
+				len(done), r.stats.Processed.Load(), r.stats.Skipped.Load(), r.stats.Failed.Load()))
+		}
+
+		slog.Info(fmt.Sprintf("Sleeping until %s", time.Now().Add(wait).Format(time.RFC3339)))
+		time.Sleep(wait)
+	}
+}
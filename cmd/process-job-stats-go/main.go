@@ -2,57 +2,82 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
 	"flag"
 	"fmt"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"runtime/pprof"
-	"sync"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/lcrownover/process-job-stats-go/internal/config"
+	"github.com/lcrownover/process-job-stats-go/internal/output"
 	"github.com/lcrownover/process-job-stats-go/internal/system"
-	"github.com/lcrownover/process-job-stats-go/internal/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var err error
-var wg sync.WaitGroup
-
-var OPEN_USE_PARTITIONS = []string{
-	"compute",
-	"compute_intel",
-	"computelong",
-	"computelong_intel",
-	"gpu",
-	"gpulong",
-	"interactive",
-	"interactivegpu",
-	"memory",
-	"memorylong",
-}
-
 func main() {
-	outputFileFlag := flag.String("output", "", "path to output file")
-	noHeaderFlag := flag.Bool("noheader", false, "don't show header row")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "statement":
+			statementMain(os.Args[2:])
+			return
+		case "serve":
+			serveMain(os.Args[2:])
+			return
+		}
+	}
+
+	var opts options
+	flag.StringVar(&opts.output, "output", "", "path to output file, use a template like out/{{.Year}}/{{.Date}}.csv for per-day files")
+	flag.StringVar(&opts.format, "format", output.FormatCSV, fmt.Sprintf("output format, one of: %s", strings.Join(output.Formats(), ", ")))
+	flag.StringVar(&opts.db, "db", "", "also upsert jobs into a database, sqlite:<path> or a postgres:// url")
+	flag.StringVar(&opts.stepsOutput, "steps-output", "", "enable step mode and write job steps as csv to this path, which may be a template like -output")
+	flag.BoolVar(&opts.prorate, "prorate", false, "only bill the part of each job that ran on the processed day, including jobs still running")
+	flag.StringVar(&opts.summaryOutput, "summary-output", "", "write per account, pi, partition and category totals as csv to this path, which may be a template like -output")
+	flag.BoolVar(&opts.summaryOnly, "summary-only", false, "only write the summary, not the per-job output")
+	flag.StringVar(&opts.gpuHoursOutput, "gpu-hours-output", "", "write the gpu hours of each job by gpu model as csv to this path, which may be a template like -output")
+	flag.StringVar(&opts.budgets, "budgets", "", "YAML file of per-account service unit budgets to track usage against")
+	flag.StringVar(&opts.ledger, "ledger", "allocation-ledger.json", "file that keeps the service units used per account per day between runs")
+	flag.StringVar(&opts.alerts, "alerts", "", "write budget threshold alerts as JSON to this path")
+	flag.StringVar(&opts.alertThresholds, "alert-thresholds", "50,80,100", "comma separated percentages of a budget to alert on")
+	flag.StringVar(&opts.metricsTextfile, "metrics-textfile", "", "write prometheus metrics to this textfile collector .prom file")
+	flag.StringVar(&opts.metricsListen, "metrics-listen", "", "serve prometheus metrics on this address, e.g. :9101, and keep serving once processing finishes")
+	flag.BoolVar(&opts.noHeader, "noheader", false, "don't show header row")
 	dayFlag := flag.String("day", "", "day to process in YYYY-mm-dd")
+	startFlag := flag.String("start", "", "first day to process in YYYY-mm-dd")
+	endFlag := flag.String("end", "", "last day to process in YYYY-mm-dd, inclusive")
+	monthFlag := flag.String("month", "", "month to process in YYYY-mm")
 	debugFlag := flag.Bool("debug", false, "show debug output")
-	workersFlag := flag.Int("workers", 16, "number of workers")
+	flag.IntVar(&opts.workers, "workers", 16, "number of workers")
 	cpuProfileFlag := flag.String("cpuprofile", "", "write cpu profile to this path")
 
-	slurmBinDirFlag := flag.String("slurm-bin-dir", "/gpfs/t2/slurm/apps/current/bin", "directory to find the slurm binaries")
-	gpfsBinDirFlag := flag.String("gpfs-bin-dir", "/usr/lpp/mmfs/bin", "directory to find the gpfs binaries")
+	configFlag := flag.String("config", os.Getenv(config.EnvPrefix+"CONFIG"), "path to YAML config file with site policy")
+	slurmBinDirFlag := flag.String("slurm-bin-dir", config.Default().SlurmBinDir, "directory to find the slurm binaries")
+	gpfsBinDirFlag := flag.String("gpfs-bin-dir", config.Default().GpfsBinDir, "directory to find the gpfs binaries")
+	scontrolFallbackFlag := flag.Bool("scontrol-fallback", false, "use scontrol to expand nodelists the built-in parser can't handle")
+
+	flag.BoolVar(&opts.skipEmptyDays, "skip-empty-days", false, "if no jobs, skip writing the output file")
+	flag.StringVar(&opts.rejects, "rejects", "", "append the raw sacct records of jobs that fail processing, with the failed stage and error, as csv to this path")
+	flag.BoolVar(&opts.strict, "strict", false, "fail a day if any of its jobs fail processing, same as -max-error-rate 0")
+	flag.Float64Var(&opts.maxErrorRate, "max-error-rate", 1, "fail a day if more than this fraction of its jobs fail processing, from 0 to 1")
+	flag.StringVar(&opts.snapshotDir, "snapshot-dir", "", "save the node partitions to this directory each run, and use the snapshot in effect on past days when processing them")
+	flag.StringVar(&opts.input, "input", "", "read saved sacct -P output from this file, or - for stdin, instead of running sacct")
 
-	skipEmptyDaysFlag := flag.Bool("skip-empty-days", false, "if no jobs, skip writing the output file")
+	daemonFlag := flag.Bool("daemon", false, "keep running, processing each day after midnight and catching up on missed days")
+	stateFileFlag := flag.String("state-file", "process-job-stats-state.json", "daemon mode: file recording the days processed successfully")
+	backfillFromFlag := flag.String("backfill-from", "", "daemon mode: first day to catch up from in YYYY-mm-dd, defaults to the earliest day in the state file")
+	daemonDelayFlag := flag.Duration("daemon-delay", time.Hour, "daemon mode: how long after midnight to process the previous day")
+	daemonRetryFlag := flag.Duration("daemon-retry", 30*time.Minute, "daemon mode: how long to wait before retrying a failed day")
 
 	flag.Parse()
 
-	logLevel := slog.LevelInfo
-	if *debugFlag {
-		logLevel = slog.LevelDebug
-	}
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
-	slog.SetDefault(logger)
+	setupLogger(*debugFlag)
 
 	if *cpuProfileFlag != "" {
 		f, err := os.Create(*cpuProfileFlag)
@@ -66,113 +91,79 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	processDayDate := time.Now().Add(-24 * time.Hour).Format("2006-01-02")
-	if *dayFlag != "" {
-		day, err := time.Parse("2006-01-02", *dayFlag)
-		if err != nil {
-			log.Fatal("Failed to parse provided date:", *dayFlag)
-		}
-		processDayDate = day.Format("2006-01-02")
+	cfg, err := config.Load(*configFlag)
+	if err != nil {
+		log.Fatal("Failed to load config: ", err)
 	}
-	slog.Debug(fmt.Sprintf("Processing jobs for day: %s", processDayDate))
-
-	output := os.Stdout
-	if *outputFileFlag != "" {
-		output, err = os.OpenFile(*outputFileFlag, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-		if err != nil {
-			log.Fatal("Error opening output file:", err)
-			return
+	// flags given on the command line win over the config file and environment
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "slurm-bin-dir":
+			cfg.SlurmBinDir = *slurmBinDirFlag
+		case "gpfs-bin-dir":
+			cfg.GpfsBinDir = *gpfsBinDirFlag
+		case "scontrol-fallback":
+			cfg.ScontrolFallback = *scontrolFallbackFlag
 		}
-	}
-	defer output.Close()
+	})
 
-	writer := csv.NewWriter(output)
-	defer writer.Flush()
+	r, err := newRunner(opts, cfg)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to set up processing: %v", err))
+		os.Exit(runOutcome(err, nil).exitCode())
+	}
+	if r.prom != nil && opts.metricsListen != "" {
+		go serveMetrics(opts.metricsListen, r.prom.Registry())
+	}
 
-	if !*noHeaderFlag {
-		err := writer.Write(system.JobKeys())
-		if err != nil {
-			log.Fatal("Failed to write to output:", err)
+	if *daemonFlag {
+		if *dayFlag != "" || *startFlag != "" || *monthFlag != "" {
+			log.Fatal("-daemon can't be combined with -day, -start or -month, use -backfill-from")
 		}
+		runDaemon(r, *stateFileFlag, *backfillFromFlag, *daemonDelayFlag, *daemonRetryFlag)
+		return
 	}
 
-	slog.Info("Starting job processing")
-
-	ctx := context.Background()
-
-	ctx = context.WithValue(ctx, types.ProcessDayKey, &processDayDate)
-	ctx = context.WithValue(ctx, types.SlurmBinDirKey, *slurmBinDirFlag)
-	ctx = context.WithValue(ctx, types.GpfsBinDirKey, *gpfsBinDirFlag)
-	ctx = context.WithValue(ctx, types.OpenUsePartitionsKey, &OPEN_USE_PARTITIONS)
-
-	rawJobData, err := system.NewRawJobData(ctx)
-	if err != nil {
-		log.Fatal("Failed to get job data:", err)
-	}
-	nodePartitions, err := system.NewNodePartitions(ctx)
+	processDays, err := system.NewProcessDays(*dayFlag, *startFlag, *endFlag, *monthFlag)
 	if err != nil {
-		log.Fatal("Failed to get node partition map:", err)
+		log.Fatal("Failed to parse days to process: ", err)
 	}
-	accountPIs, err := system.NewAccountPIs(ctx)
-	if err != nil {
-		log.Fatal("Failed to get account pi map:", err)
-	}
-	accountStorages, err := system.NewAccountStorages(ctx)
+	slog.Debug(fmt.Sprintf("Processing jobs for days: %s -> %s", processDays[0], processDays[len(processDays)-1]))
+
+	slog.Info("Starting job processing")
+	_, err = r.runDays(processDays, false)
 	if err != nil {
-		log.Fatal("Failed to get account storage map:", err)
-	}
-	nlc := system.NewNodeListCache()
-	ulc := system.NewUserListCache()
-
-	ctx = context.WithValue(ctx, types.NodePartitionsKey, nodePartitions)
-	ctx = context.WithValue(ctx, types.AccountPIsKey, accountPIs)
-	ctx = context.WithValue(ctx, types.AccountStoragesKey, accountStorages)
-	ctx = context.WithValue(ctx, types.NodeListCacheKey, nlc)
-	ctx = context.WithValue(ctx, types.UserListCacheKey, ulc)
-
-	jobCount := len(rawJobData.Jobs)
-	workCh := make(chan string, jobCount)
-	resultCh := make(chan *system.Job, jobCount)
-
-	slog.Info(fmt.Sprintf("Processing %d jobs", jobCount))
-
-	workerCount := *workersFlag
-	for range workerCount {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			worker(ctx, workCh, resultCh)
-		}()
+		slog.Error(fmt.Sprintf("Failed to process jobs: %v", err))
 	}
+	slog.Info(fmt.Sprintf("Finished: %d processed, %d skipped, %d failed", r.stats.Processed.Load(), r.stats.Skipped.Load(), r.stats.Failed.Load()))
 
-	for _, js := range rawJobData.Jobs {
-		workCh <- js
+	if *cpuProfileFlag != "" {
+		pprof.StopCPUProfile()
 	}
-	close(workCh)
-
-	go func() {
-		wg.Wait()
-		close(resultCh)
-	}()
+	code := runOutcome(err, r.stats).exitCode()
+	if opts.metricsListen != "" {
+		slog.Info(fmt.Sprintf("Serving metrics on %s until interrupted, then exiting with code %d", opts.metricsListen, code))
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		<-ctx.Done()
+		stop()
+	}
+	os.Exit(code)
+}
 
-	for job := range resultCh {
-		if job != nil && !*skipEmptyDaysFlag {
-			writer.Write(job.Fields())
-		}
+func serveMetrics(addr string, registry *prometheus.Registry) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatal("Failed to serve metrics: ", err)
 	}
 }
 
-func worker(ctx context.Context, jobs <-chan string, results chan<- *system.Job) {
-	for j := range jobs {
-		job, err := system.NewJob(ctx, j)
-		if err != nil {
-			slog.Error(fmt.Sprintf("Failed to parse job: %v", err))
-			continue
-		}
-		if job == nil {
-			slog.Info(fmt.Sprintf("Skipping job: %s", j))
-			continue
-		}
-		results <- job
+func setupLogger(debug bool) {
+	logLevel := slog.LevelInfo
+	if debug {
+		logLevel = slog.LevelDebug
 	}
+	// jobs are written to stdout when there's no -output, keep the logs out of it
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+	slog.SetDefault(logger)
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+// Exit codes, so wrappers like cron jobs can tell a good run from a bad one.
+// 2 is left to the flag package for usage errors.
+const (
+	exitSuccess       = 0
+	exitFailure       = 1
+	exitPartial       = 3
+	exitJobErrors     = 4
+	exitSourceFailure = 5
+	exitLookupFailure = 6
+)
+
+// outcome is how a run ended.
+type outcome int
+
+const (
+	// every job was processed
+	outcomeSuccess outcome = iota
+	// some jobs failed, but no more than -max-error-rate
+	outcomePartial
+	// a day had more failed jobs than -strict or -max-error-rate allow
+	outcomeJobErrors
+	// sacct or the input file couldn't be read
+	outcomeSourceFailure
+	// a lookup table like the node partitions or account PIs couldn't be built
+	outcomeLookupFailure
+	// anything else, e.g. an output that couldn't be written
+	outcomeFailure
+)
+
+func (o outcome) exitCode() int {
+	switch o {
+	case outcomeSuccess:
+		return exitSuccess
+	case outcomePartial:
+		return exitPartial
+	case outcomeJobErrors:
+		return exitJobErrors
+	case outcomeSourceFailure:
+		return exitSourceFailure
+	case outcomeLookupFailure:
+		return exitLookupFailure
+	}
+	return exitFailure
+}
+
+// runError is an error that ended a run along with its outcome.
+type runError struct {
+	outcome outcome
+	err     error
+}
+
+func (e *runError) Error() string {
+	return e.err.Error()
+}
+
+func (e *runError) Unwrap() error {
+	return e.err
+}
+
+// runOutcome returns the outcome of a run from the error that ended it and
+// the job counts, which are only looked at when there's no error.
+func runOutcome(err error, stats *system.RunStats) outcome {
+	if err != nil {
+		var re *runError
+		if errors.As(err, &re) {
+			return re.outcome
+		}
+		return outcomeFailure
+	}
+	if stats.Failed.Load() > 0 {
+		return outcomePartial
+	}
+	return outcomeSuccess
+}
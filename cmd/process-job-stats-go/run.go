@@ -0,0 +1,444 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lcrownover/process-job-stats-go/internal/allocation"
+	"github.com/lcrownover/process-job-stats-go/internal/config"
+	"github.com/lcrownover/process-job-stats-go/internal/output"
+	"github.com/lcrownover/process-job-stats-go/internal/snapshot"
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+// options are the processing flags shared by one-shot and daemon runs.
+type options struct {
+	output          string
+	format          string
+	db              string
+	stepsOutput     string
+	prorate         bool
+	summaryOutput   string
+	summaryOnly     bool
+	gpuHoursOutput  string
+	budgets         string
+	ledger          string
+	alerts          string
+	alertThresholds string
+	metricsTextfile string
+	metricsListen   string
+	noHeader        bool
+	workers         int
+	skipEmptyDays   bool
+	input           string
+	rejects         string
+	strict          bool
+	maxErrorRate    float64
+	snapshotDir     string
+}
+
+// runner processes days of jobs. The job source and metrics live for the
+// whole process, the lookup tables and outputs are rebuilt for each run.
+type runner struct {
+	opts   options
+	cfg    *config.Config
+	source system.JobSource
+	stats  *system.RunStats
+	prom   *output.Prometheus
+	// snapshots is nil unless partition snapshots are enabled
+	snapshots *snapshot.Store
+}
+
+func newRunner(opts options, cfg *config.Config) (*runner, error) {
+	if opts.summaryOnly && opts.summaryOutput == "" {
+		return nil, fmt.Errorf("-summary-only requires -summary-output")
+	}
+	if opts.maxErrorRate < 0 || opts.maxErrorRate > 1 {
+		return nil, fmt.Errorf("-max-error-rate must be between 0 and 1")
+	}
+	if opts.strict {
+		opts.maxErrorRate = 0
+	}
+	r := &runner{
+		opts:   opts,
+		cfg:    cfg,
+		source: system.NewSacctJobSource(),
+		stats:  system.NewRunStats(),
+	}
+	if opts.input != "" {
+		source, err := system.NewFileJobSource(opts.input)
+		if err != nil {
+			return nil, &runError{outcomeSourceFailure, fmt.Errorf("failed to read job input: %v", err)}
+		}
+		r.source = source
+	}
+	if opts.metricsTextfile != "" || opts.metricsListen != "" {
+		r.prom = output.NewPrometheus(opts.metricsTextfile, r.stats)
+	}
+	if opts.snapshotDir != "" {
+		store, err := snapshot.NewStore(opts.snapshotDir)
+		if err != nil {
+			return nil, err
+		}
+		r.snapshots = store
+	}
+	return r, nil
+}
+
+func (r *runner) openOutputs() (output.Output, error) {
+	o := r.opts
+	outputs := []output.Output{}
+	// anything opened before a failure is closed by the caller's Close
+	fail := func(err error) (output.Output, error) {
+		output.NewMulti(outputs...).Close()
+		return nil, err
+	}
+	if !o.summaryOnly && (o.output != "" || o.db == "") {
+		sink, err := output.NewSink(o.output, o.format, !o.noHeader)
+		if err != nil {
+			return fail(fmt.Errorf("failed to open output: %v", err))
+		}
+		outputs = append(outputs, sink)
+	}
+	if o.db != "" {
+		db, err := output.NewDB(o.db)
+		if err != nil {
+			return fail(fmt.Errorf("failed to open database: %v", err))
+		}
+		outputs = append(outputs, db)
+	}
+	if o.stepsOutput != "" {
+		steps, err := output.NewSink(o.stepsOutput, output.FormatSteps, !o.noHeader)
+		if err != nil {
+			return fail(fmt.Errorf("failed to open steps output: %v", err))
+		}
+		outputs = append(outputs, steps)
+	}
+	if o.summaryOutput != "" {
+		summary, err := output.NewSink(o.summaryOutput, output.FormatSummary, !o.noHeader)
+		if err != nil {
+			return fail(fmt.Errorf("failed to open summary output: %v", err))
+		}
+		outputs = append(outputs, summary)
+	}
+	if o.gpuHoursOutput != "" {
+		gpuHours, err := output.NewSink(o.gpuHoursOutput, output.FormatGPUHours, !o.noHeader)
+		if err != nil {
+			return fail(fmt.Errorf("failed to open gpu hours output: %v", err))
+		}
+		outputs = append(outputs, gpuHours)
+	}
+	if o.budgets != "" {
+		budgets, err := allocation.LoadBudgets(o.budgets)
+		if err != nil {
+			return fail(fmt.Errorf("failed to load budgets: %v", err))
+		}
+		thresholds := []float64{}
+		for _, t := range config.SplitList(o.alertThresholds) {
+			v, err := strconv.ParseFloat(t, 64)
+			if err != nil {
+				return fail(fmt.Errorf("failed to parse alert threshold: %s", t))
+			}
+			thresholds = append(thresholds, v)
+		}
+		ledger, err := allocation.NewLedger(o.ledger, o.alerts, budgets, thresholds)
+		if err != nil {
+			return fail(fmt.Errorf("failed to open allocation ledger: %v", err))
+		}
+		outputs = append(outputs, ledger)
+	}
+	if r.prom != nil {
+		outputs = append(outputs, r.prom)
+	}
+	return output.NewMulti(outputs...), nil
+}
+
+// lookupContext builds the context holding the site policy and the lookup
+// tables and caches that are shared by every day of a run.
+func (r *runner) lookupContext() (context.Context, error) {
+	ctx := context.Background()
+
+	ctx = context.WithValue(ctx, types.SlurmBinDirKey, r.cfg.SlurmBinDir)
+	ctx = context.WithValue(ctx, types.GpfsBinDirKey, r.cfg.GpfsBinDir)
+	ctx = context.WithValue(ctx, types.GpfsFilesystemKey, r.cfg.GpfsFilesystem)
+	ctx = context.WithValue(ctx, types.ProjectsDirKey, r.cfg.ProjectsDir)
+	ctx = context.WithValue(ctx, types.ScontrolFallbackKey, r.cfg.ScontrolFallback)
+	ctx = context.WithValue(ctx, types.ScontrolJobDetailKey, r.cfg.ScontrolJobDetail)
+	ctx = context.WithValue(ctx, types.OpenUsePartitionsKey, &r.cfg.OpenUsePartitions)
+	ctx = context.WithValue(ctx, types.PreemptPartitionKey, r.cfg.PreemptPartition)
+	ctx = context.WithValue(ctx, types.NodePrecedenceKey, r.cfg.NodePrecedence)
+	ctx = context.WithValue(ctx, types.ServiceUnitRatesKey, r.cfg.ServiceUnitRates)
+	ctx = context.WithValue(ctx, types.JobStatesKey, r.cfg.JobStates)
+	ctx = context.WithValue(ctx, types.ChargeModelKey, r.cfg.ChargeModel)
+	ctx = context.WithValue(ctx, types.ProrateKey, r.opts.prorate)
+	ctx = context.WithValue(ctx, types.StepModeKey, r.opts.stepsOutput != "")
+
+	nodePartitions, err := system.NewNodePartitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node partition map: %v", err)
+	}
+	if r.snapshots != nil {
+		err := r.snapshots.Save(&snapshot.Snapshot{
+			Date:              time.Now().Format("2006-01-02"),
+			NodePartitions:    nodePartitions.Map(),
+			OpenUsePartitions: r.cfg.OpenUsePartitions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to save partition snapshot: %v", err)
+		}
+	}
+	accountPIs, err := system.NewAccountPIs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account pi map: %v", err)
+	}
+	accountStorages, err := system.NewAccountStorages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account storage map: %v", err)
+	}
+	nodeGres, err := system.NewNodeGres(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node gpu map: %v", err)
+	}
+	if r.cfg.ChargeModel == types.ChargeModelEffectiveCores {
+		nodeMemory, err := system.NewNodeMemory(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node memory map: %v", err)
+		}
+		ctx = context.WithValue(ctx, types.NodeMemoryKey, nodeMemory)
+	}
+	nlc := system.NewNodeListCache()
+	ulc := system.NewUserListCache()
+
+	ctx = context.WithValue(ctx, types.NodePartitionsKey, nodePartitions)
+	ctx = context.WithValue(ctx, types.AccountPIsKey, accountPIs)
+	ctx = context.WithValue(ctx, types.AccountStoragesKey, accountStorages)
+	ctx = context.WithValue(ctx, types.NodeGresKey, nodeGres)
+	ctx = context.WithValue(ctx, types.NodeListCacheKey, nlc)
+	ctx = context.WithValue(ctx, types.UserListCacheKey, ulc)
+	return ctx, nil
+}
+
+// runDays processes the days in order and returns the ones that finished
+// and whose output was written. It stops at the first day that fails, unless
+// skipJobErrors is set and the day only had too many failed jobs, in which
+// case it moves on to the next day and returns the day's error at the end.
+func (r *runner) runDays(days []string, skipJobErrors bool) ([]string, error) {
+	done := []string{}
+
+	sink, err := r.openOutputs()
+	if err != nil {
+		return done, err
+	}
+	defer sink.Close()
+
+	var rejects *output.Rejects
+	if r.opts.rejects != "" {
+		rejects, err = output.NewRejects(r.opts.rejects, !r.opts.noHeader)
+		if err != nil {
+			return done, err
+		}
+		defer rejects.Close()
+	}
+
+	ctx, err := r.lookupContext()
+	if err != nil {
+		return done, &runError{outcomeLookupFailure, err}
+	}
+
+	var runErr error
+	finisher, _ := sink.(output.DayFinisher)
+	for _, day := range days {
+		err := r.runDay(ctx, sink, rejects, day)
+		if finisher != nil {
+			finisher.FinishDay(err == nil)
+		}
+		if err == nil {
+			done = append(done, day)
+			continue
+		}
+		err = fmt.Errorf("failed to process %s: %w", day, err)
+		runErr = errors.Join(runErr, err)
+		if skipJobErrors && runOutcome(err, r.stats) == outcomeJobErrors {
+			slog.Error(fmt.Sprintf("Moving on past %s: %v", day, err))
+			continue
+		}
+		break
+	}
+
+	// days only count as done once their output is finalized, e.g. the
+	// parquet footer is written or the summary is flushed
+	if err := sink.Close(); err != nil {
+		return nil, errors.Join(runErr, fmt.Errorf("failed to write to output: %v", err))
+	}
+	if rejects != nil {
+		if err := rejects.Close(); err != nil {
+			return done, errors.Join(runErr, fmt.Errorf("failed to write rejects: %v", err))
+		}
+	}
+	return done, runErr
+}
+
+func (r *runner) runDay(ctx context.Context, sink output.Output, rejects *output.Rejects, processDayDate string) error {
+	dayCtx := context.WithValue(ctx, types.ProcessDayKey, &processDayDate)
+	dayCtx, err := r.snapshotContext(dayCtx, processDayDate)
+	if err != nil {
+		return &runError{outcomeLookupFailure, err}
+	}
+
+	rawJobData, err := r.source.Jobs(dayCtx)
+	if err != nil {
+		return &runError{outcomeSourceFailure, fmt.Errorf("failed to get job data: %v", err)}
+	}
+
+	jobCount := len(rawJobData.Jobs)
+	slog.Info(fmt.Sprintf("Processing %d jobs for %s", jobCount, processDayDate))
+	if jobCount == 0 && r.opts.skipEmptyDays {
+		slog.Info(fmt.Sprintf("Skipping empty day: %s", processDayDate))
+		return nil
+	}
+
+	if r.opts.stepsOutput != "" {
+		steps := system.NewJobSteps(rawJobData.Steps)
+		slog.Info(fmt.Sprintf("Parsed steps for %d jobs", len(steps)))
+		dayCtx = context.WithValue(dayCtx, types.JobStepsKey, steps)
+	}
+
+	writer, err := sink.Day(processDayDate)
+	if err != nil {
+		return fmt.Errorf("failed to open output: %v", err)
+	}
+
+	processed, failed, nodeCounted := 0, 0, 0
+	var writeErr error
+	processJobs(dayCtx, rawJobData.Jobs, r.opts.workers, r.stats, func(job *system.Job) {
+		processed++
+		if job.NodeCountedWeights {
+			nodeCounted++
+		}
+		if err := writer.Write(job); err != nil {
+			slog.Error(fmt.Sprintf("Failed to write job %s: %v", job.JobID, err))
+			if writeErr == nil {
+				writeErr = err
+			}
+		}
+	}, func(record string, jobErr error) {
+		failed++
+		if rejects == nil {
+			return
+		}
+		if err := rejects.Write(processDayDate, record, jobErr); err != nil {
+			slog.Error(fmt.Sprintf("Failed to write rejected job: %v", err))
+		}
+	})
+
+	if nodeCounted > 0 {
+		slog.Info(fmt.Sprintf("Weighted %d jobs on open-use and condo nodes by node count, scontrol didn't have all their per node cpus", nodeCounted))
+	}
+
+	// ending the day rolls back outputs like the database that a job failed
+	// to write to, so the day fails either way
+	if err := sink.EndDay(); err != nil {
+		return fmt.Errorf("failed to write to output: %v", err)
+	}
+	if writeErr != nil {
+		return fmt.Errorf("failed to write to output: %v", writeErr)
+	}
+
+	// the day's output is still written so the failed jobs can be compared
+	// against it, but the day doesn't count as done
+	if failed > 0 {
+		rate := float64(failed) / float64(processed+failed)
+		if rate > r.opts.maxErrorRate {
+			return &runError{outcomeJobErrors, fmt.Errorf("%d of %d jobs failed, over the allowed error rate of %g", failed, processed+failed, r.opts.maxErrorRate)}
+		}
+	}
+	return nil
+}
+
+// snapshotContext swaps the live node partitions and open-use partitions for
+// the snapshot in effect on a past day. Today's snapshot is the live one, and
+// days from before the first snapshot use the live ones too.
+func (r *runner) snapshotContext(ctx context.Context, day string) (context.Context, error) {
+	if r.snapshots == nil || day >= time.Now().Format("2006-01-02") {
+		return ctx, nil
+	}
+	snap, err := r.snapshots.Effective(day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load partition snapshot: %v", err)
+	}
+	if snap == nil {
+		slog.Warn(fmt.Sprintf("No partition snapshot on or before %s, using the current partitions", day))
+		return ctx, nil
+	}
+	slog.Info(fmt.Sprintf("Using partition snapshot from %s for %s", snap.Date, day))
+	ctx = context.WithValue(ctx, types.NodePartitionsKey, system.NodePartitionsFromMap(snap.NodePartitions))
+	ctx = context.WithValue(ctx, types.OpenUsePartitionsKey, &snap.OpenUsePartitions)
+	return ctx, nil
+}
+
+// jobResult is a parsed job, or the record and error of one that failed.
+type jobResult struct {
+	job    *system.Job
+	record string
+	err    error
+}
+
+// processJobs fans the raw job strings out to the workers and calls handle
+// with each parsed job, or reject with each record that failed, from the
+// calling goroutine.
+func processJobs(ctx context.Context, jobs []string, workerCount int, stats *system.RunStats, handle func(*system.Job), reject func(string, error)) {
+	var wg sync.WaitGroup
+	workCh := make(chan string, len(jobs))
+	resultCh := make(chan jobResult, len(jobs))
+
+	for range workerCount {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker(ctx, workCh, resultCh, stats)
+		}()
+	}
+
+	for _, js := range jobs {
+		workCh <- js
+	}
+	close(workCh)
+
+	go func() {
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	for res := range resultCh {
+		if res.err != nil {
+			reject(res.record, res.err)
+			continue
+		}
+		handle(res.job)
+	}
+}
+
+func worker(ctx context.Context, jobs <-chan string, results chan<- jobResult, stats *system.RunStats) {
+	for j := range jobs {
+		job, err := system.NewJob(ctx, j)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to parse job at %s stage: %v", system.JobErrorStage(err), err))
+			stats.Failed.Add(1)
+			results <- jobResult{record: j, err: err}
+			continue
+		}
+		if job == nil {
+			slog.Info(fmt.Sprintf("Skipping job: %s", j))
+			stats.Skipped.Add(1)
+			continue
+		}
+		stats.Processed.Add(1)
+		results <- jobResult{job: job}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/lcrownover/process-job-stats-go/internal/server"
+)
+
+// serveMain serves a JSON API over a directory of processed csv output.
+//
+//	process-job-stats serve -data-dir out -listen :8080
+func serveMain(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	dataDirFlag := fs.String("data-dir", ".", "directory of processed csv output to index")
+	listenFlag := fs.String("listen", ":8080", "address to serve the API on")
+	reloadFlag := fs.Duration("reload", 5*time.Minute, "how often to re-index the data dir, 0 to disable")
+	debugFlag := fs.Bool("debug", false, "show debug output")
+	fs.Parse(args)
+
+	setupLogger(*debugFlag)
+
+	idx, err := server.NewIndex(*dataDirFlag)
+	if err != nil {
+		log.Fatal("Failed to index processed output: ", err)
+	}
+
+	if *reloadFlag > 0 {
+		go func() {
+			for range time.Tick(*reloadFlag) {
+				if err := idx.Reload(); err != nil {
+					slog.Error(fmt.Sprintf("Failed to re-index processed output: %v", err))
+				}
+			}
+		}()
+	}
+
+	slog.Info(fmt.Sprintf("Serving API on %s", *listenFlag))
+	if err := http.ListenAndServe(*listenFlag, server.Handler(idx)); err != nil {
+		log.Fatal("Failed to serve API: ", err)
+	}
+}
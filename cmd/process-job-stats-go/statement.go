@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lcrownover/process-job-stats-go/internal/report"
+)
+
+// statementMain renders a statement per PI from a month of csv output.
+//
+//	process-job-stats statement -month 2025-01 -format pdf out/2025/*.csv
+func statementMain(args []string) {
+	fs := flag.NewFlagSet("statement", flag.ExitOnError)
+	monthFlag := fs.String("month", "", "month to bill in YYYY-mm")
+	formatFlag := fs.String("format", report.FormatMarkdown, fmt.Sprintf("statement format, one of: %s", strings.Join(report.Formats(), ", ")))
+	outputDirFlag := fs.String("output-dir", ".", "directory to write the statements to")
+	debugFlag := fs.Bool("debug", false, "show debug output")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s statement [flags] <processed csv files>\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	setupLogger(*debugFlag)
+
+	if _, err := time.Parse("2006-01", *monthFlag); err != nil {
+		log.Fatal("Failed to parse month, expected YYYY-mm: ", *monthFlag)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	jobs, err := report.ReadJobsCSV(fs.Args())
+	if err != nil {
+		log.Fatal("Failed to read processed output: ", err)
+	}
+	statements := report.NewStatements(*monthFlag, jobs)
+	slog.Info(fmt.Sprintf("Writing %d statements for %s", len(statements), *monthFlag))
+
+	if err := os.MkdirAll(*outputDirFlag, 0755); err != nil {
+		log.Fatal("Failed to create output directory: ", err)
+	}
+	for _, st := range statements {
+		name := st.PIUsername
+		if name == "" {
+			name = "unknown"
+		}
+		path := filepath.Join(*outputDirFlag, fmt.Sprintf("%s-%s.%s", name, *monthFlag, *formatFlag))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			log.Fatal("Failed to open statement file: ", err)
+		}
+		err = st.Render(f, *formatFlag)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Failed to write statement for %s: %v", st.PIUsername, err))
+		}
+		slog.Debug(fmt.Sprintf("  Wrote statement: %s", path))
+	}
+}
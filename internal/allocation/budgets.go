@@ -0,0 +1,68 @@
+package allocation
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Budget is the service units allocated to an account between two days,
+// both inclusive.
+type Budget struct {
+	Account      string  `yaml:"account" json:"account"`
+	Start        string  `yaml:"start" json:"start"`
+	End          string  `yaml:"end" json:"end"`
+	ServiceUnits float64 `yaml:"service_units" json:"service_units"`
+}
+
+type budgetsFile struct {
+	Budgets []Budget `yaml:"budgets"`
+}
+
+// LoadBudgets reads the budgets from a YAML file of the form:
+//
+//	budgets:
+//	  - account: kernlab
+//	    start: 2025-01-01
+//	    end: 2025-06-30
+//	    service_units: 10000
+func LoadBudgets(path string) ([]Budget, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read budgets file: %v", err)
+	}
+	var bf budgetsFile
+	if err := yaml.Unmarshal(b, &bf); err != nil {
+		return nil, fmt.Errorf("failed to parse budgets file: %v", err)
+	}
+	for i, bu := range bf.Budgets {
+		if bu.Account == "" {
+			return nil, fmt.Errorf("budget %d has no account", i)
+		}
+		s, err := time.Parse("2006-01-02", bu.Start)
+		if err != nil {
+			return nil, fmt.Errorf("budget %d for %s has invalid start: %s", i, bu.Account, bu.Start)
+		}
+		e, err := time.Parse("2006-01-02", bu.End)
+		if err != nil {
+			return nil, fmt.Errorf("budget %d for %s has invalid end: %s", i, bu.Account, bu.End)
+		}
+		if e.Before(s) {
+			return nil, fmt.Errorf("budget %d for %s ends before it starts", i, bu.Account)
+		}
+		if bu.ServiceUnits <= 0 {
+			return nil, fmt.Errorf("budget %d for %s must have positive service_units", i, bu.Account)
+		}
+	}
+	return bf.Budgets, nil
+}
+
+func (b Budget) key() string {
+	return fmt.Sprintf("%s/%s/%s", b.Account, b.Start, b.End)
+}
+
+func (b Budget) contains(day string) bool {
+	return day >= b.Start && day <= b.End
+}
@@ -0,0 +1,230 @@
+package allocation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/lcrownover/process-job-stats-go/internal/output"
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+// ledgerState is what's persisted between runs. Usage is stored per day so
+// reprocessing a day replaces its service units instead of adding them twice.
+type ledgerState struct {
+	// account -> day -> service units
+	Usage map[string]map[string]float64 `json:"usage"`
+	// budget key -> thresholds already alerted on
+	Alerted map[string][]float64 `json:"alerted"`
+}
+
+// Alert is written when an account's usage crosses a threshold of its budget.
+type Alert struct {
+	Budget
+	Used      float64 `json:"used"`
+	Percent   float64 `json:"percent"`
+	Threshold float64 `json:"threshold"`
+	Date      string  `json:"date"`
+}
+
+// Ledger tracks service unit consumption against budgets. It's an
+// output.Output so it receives the processed jobs alongside the other outputs.
+type Ledger struct {
+	path       string
+	alertsPath string
+	budgets    []Budget
+	thresholds []float64
+	state      *ledgerState
+
+	day  string
+	used map[string]float64
+	// the last ended day, kept until FinishDay says whether it succeeded
+	endedDay string
+	ended    map[string]float64
+}
+
+// NewLedger loads the ledger state from path, starting a new one if it
+// doesn't exist yet. Thresholds are percentages of the budget.
+func NewLedger(path, alertsPath string, budgets []Budget, thresholds []float64) (*Ledger, error) {
+	st := &ledgerState{
+		Usage:   make(map[string]map[string]float64),
+		Alerted: make(map[string][]float64),
+	}
+	b, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read ledger: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, st); err != nil {
+			return nil, fmt.Errorf("failed to parse ledger: %v", err)
+		}
+	}
+	thresholds = slices.Clone(thresholds)
+	sort.Float64s(thresholds)
+	return &Ledger{
+		path:       path,
+		alertsPath: alertsPath,
+		budgets:    budgets,
+		thresholds: thresholds,
+		state:      st,
+	}, nil
+}
+
+func (l *Ledger) Day(day string) (output.JobWriter, error) {
+	if l.used != nil && l.day == day {
+		return &ledgerJobWriter{l}, nil
+	}
+	if err := l.EndDay(); err != nil {
+		return nil, err
+	}
+	l.day = day
+	l.used = make(map[string]float64)
+	return &ledgerJobWriter{l}, nil
+}
+
+// EndDay holds on to the day's service units per account until FinishDay.
+func (l *Ledger) EndDay() error {
+	if l.used == nil {
+		return nil
+	}
+	l.endedDay = l.day
+	l.ended = l.used
+	l.used = nil
+	return nil
+}
+
+// FinishDay records the ended day's service units, replacing whatever the
+// ledger held for that day, when the day succeeded. A failed day, e.g. one
+// the database rolled back, keeps its previous usage until it's reprocessed.
+func (l *Ledger) FinishDay(ok bool) {
+	if l.ended == nil {
+		return
+	}
+	if !ok {
+		slog.Debug(fmt.Sprintf("  Discarding allocation usage for failed day %s", l.endedDay))
+		l.ended = nil
+		return
+	}
+	for account, days := range l.state.Usage {
+		delete(days, l.endedDay)
+		if len(days) == 0 {
+			delete(l.state.Usage, account)
+		}
+	}
+	for account, su := range l.ended {
+		if l.state.Usage[account] == nil {
+			l.state.Usage[account] = make(map[string]float64)
+		}
+		l.state.Usage[account][l.endedDay] = su
+	}
+	l.ended = nil
+}
+
+// Close checks the budgets for newly crossed thresholds, logs and writes
+// any alerts, and saves the ledger. A day that wasn't finished isn't
+// recorded.
+func (l *Ledger) Close() error {
+	if err := l.EndDay(); err != nil {
+		return err
+	}
+	l.ended = nil
+	alerts := l.checkBudgets()
+	for _, a := range alerts {
+		slog.Warn(fmt.Sprintf("Allocation alert: %s has used %.1f%% (%.2f of %.2f SU) of its %s -> %s budget, crossing %.0f%%",
+			a.Account, a.Percent, a.Used, a.ServiceUnits, a.Start, a.End, a.Threshold))
+	}
+	if l.alertsPath != "" {
+		if err := writeJSON(l.alertsPath, alerts); err != nil {
+			return fmt.Errorf("failed to write alerts: %v", err)
+		}
+	}
+	if err := writeJSON(l.path, l.state); err != nil {
+		return fmt.Errorf("failed to write ledger: %v", err)
+	}
+	return nil
+}
+
+// Used returns the service units an account consumed within a budget.
+func (l *Ledger) Used(b Budget) float64 {
+	used := 0.0
+	for day, su := range l.state.Usage[b.Account] {
+		if b.contains(day) {
+			used += su
+		}
+	}
+	return used
+}
+
+func (l *Ledger) checkBudgets() []Alert {
+	alerts := []Alert{}
+	for _, b := range l.budgets {
+		used := l.Used(b)
+		percent := used / b.ServiceUnits * 100
+		slog.Info(fmt.Sprintf("Allocation: %s used %.2f of %.2f SU (%.1f%%) for %s -> %s",
+			b.Account, used, b.ServiceUnits, percent, b.Start, b.End))
+
+		alerted := l.state.Alerted[b.key()]
+		for _, t := range l.thresholds {
+			if percent < t || slices.Contains(alerted, t) {
+				continue
+			}
+			alerts = append(alerts, Alert{
+				Budget:    b,
+				Used:      used,
+				Percent:   percent,
+				Threshold: t,
+				Date:      l.lastDay(b),
+			})
+			alerted = append(alerted, t)
+		}
+		// usage can drop when a day is reprocessed, allow alerting again
+		alerted = slices.DeleteFunc(alerted, func(t float64) bool { return percent < t })
+		if len(alerted) > 0 {
+			l.state.Alerted[b.key()] = alerted
+		} else {
+			delete(l.state.Alerted, b.key())
+		}
+	}
+	return alerts
+}
+
+// lastDay is the latest day with usage recorded inside the budget.
+func (l *Ledger) lastDay(b Budget) string {
+	last := ""
+	for day := range l.state.Usage[b.Account] {
+		if b.contains(day) && day > last {
+			last = day
+		}
+	}
+	return last
+}
+
+func writeJSON(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+type ledgerJobWriter struct {
+	ledger *Ledger
+}
+
+func (w *ledgerJobWriter) Write(j *system.Job) error {
+	w.ledger.used[j.Account] += j.ServiceUnits
+	return nil
+}
+
+// Close is a no-op, the day is recorded by Ledger.FinishDay.
+func (w *ledgerJobWriter) Close() error {
+	return nil
+}
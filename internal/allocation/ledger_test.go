@@ -0,0 +1,104 @@
+package allocation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+var testBudget = Budget{Account: "hpc", Start: "2025-01-01", End: "2025-01-31", ServiceUnits: 100}
+
+// runLedgerDay runs one day through a ledger loaded from dir, the way the
+// runner does, and returns the alerts written when it's closed.
+func runLedgerDay(t *testing.T, dir, day string, su float64, ok bool) (*Ledger, []Alert) {
+	t.Helper()
+	alertsPath := filepath.Join(dir, "alerts.json")
+	l, err := NewLedger(filepath.Join(dir, "ledger.json"), alertsPath, []Budget{testBudget}, []float64{80, 50})
+	if err != nil {
+		t.Fatalf("NewLedger error: %v", err)
+	}
+	w, err := l.Day(day)
+	if err != nil {
+		t.Fatalf("Day error: %v", err)
+	}
+	if err := w.Write(&system.Job{Account: "hpc", ServiceUnits: su}); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	if err := l.EndDay(); err != nil {
+		t.Fatalf("EndDay error: %v", err)
+	}
+	l.FinishDay(ok)
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	b, err := os.ReadFile(alertsPath)
+	if err != nil {
+		t.Fatalf("failed to read alerts: %v", err)
+	}
+	alerts := []Alert{}
+	if err := json.Unmarshal(b, &alerts); err != nil {
+		t.Fatalf("failed to parse alerts: %v", err)
+	}
+	return l, alerts
+}
+
+func alertThresholds(alerts []Alert) []float64 {
+	thresholds := []float64{}
+	for _, a := range alerts {
+		thresholds = append(thresholds, a.Threshold)
+	}
+	return thresholds
+}
+
+func TestLedgerReplacesDay(t *testing.T) {
+	dir := t.TempDir()
+	runLedgerDay(t, dir, "2025-01-02", 10, true)
+	runLedgerDay(t, dir, "2025-01-03", 5, true)
+	l, _ := runLedgerDay(t, dir, "2025-01-02", 4, true)
+	if got := l.Used(testBudget); got != 9 {
+		t.Errorf("Used after reprocessing a day = %f, want 9", got)
+	}
+}
+
+func TestLedgerDiscardsFailedDay(t *testing.T) {
+	dir := t.TempDir()
+	runLedgerDay(t, dir, "2025-01-02", 10, true)
+	l, alerts := runLedgerDay(t, dir, "2025-01-02", 90, false)
+	if got := l.Used(testBudget); got != 10 {
+		t.Errorf("Used after a failed day = %f, want 10", got)
+	}
+	if len(alerts) != 0 {
+		t.Errorf("alerts after a failed day = %v, want none", alertThresholds(alerts))
+	}
+	l, _ = runLedgerDay(t, dir, "2025-01-03", 5, false)
+	if got := l.Used(testBudget); got != 10 {
+		t.Errorf("Used after a failed new day = %f, want 10", got)
+	}
+}
+
+func TestLedgerAlerts(t *testing.T) {
+	dir := t.TempDir()
+	steps := []struct {
+		name string
+		day  string
+		su   float64
+		want []float64
+	}{
+		{"crossing a threshold alerts", "2025-01-02", 60, []float64{50}},
+		{"the same threshold doesn't alert again", "2025-01-02", 65, []float64{}},
+		{"crossing the next threshold alerts", "2025-01-03", 30, []float64{80}},
+		{"usage outside the budget doesn't count", "2025-02-01", 100, []float64{}},
+		{"dropping below a threshold", "2025-01-02", 10, []float64{}},
+		{"crossing it again alerts again", "2025-01-02", 60, []float64{50, 80}},
+	}
+	for _, s := range steps {
+		_, alerts := runLedgerDay(t, dir, s.day, s.su, true)
+		if got := alertThresholds(alerts); !slices.Equal(got, s.want) {
+			t.Errorf("%s: alerted on %v, want %v", s.name, got, s.want)
+		}
+	}
+}
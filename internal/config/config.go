@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is prepended to the environment variable overrides, e.g.
+// PJS_SLURM_BIN_DIR.
+const EnvPrefix = "PJS_"
+
+// Config holds the site policy that differs between clusters. Values are
+// layered as defaults, then the config file, then environment variables, and
+// finally any command line flags that were explicitly set.
+type Config struct {
+	SlurmBinDir       string   `yaml:"slurm_bin_dir"`
+	GpfsBinDir        string   `yaml:"gpfs_bin_dir"`
+	GpfsFilesystem    string   `yaml:"gpfs_filesystem"`
+	ProjectsDir       string   `yaml:"projects_dir"`
+	OpenUsePartitions []string `yaml:"open_use_partitions"`
+	PreemptPartition  string   `yaml:"preempt_partition"`
+	ScontrolFallback  bool     `yaml:"scontrol_fallback"`
+
+	// NodePrecedence decides how nodes in both open-use and condo partitions
+	// are counted: openuse, condo or shared
+	NodePrecedence string `yaml:"node_precedence"`
+	// ScontrolJobDetail weights jobs that ran on both open-use and condo
+	// nodes by their CPUs on each node, as reported by scontrol. It's best
+	// effort and usually unavailable: slurm drops the detail after MinJobAge
+	// and sacct never stores it, so a daily run for yesterday counts nodes
+	ScontrolJobDetail bool `yaml:"scontrol_job_detail"`
+
+	// JobStates are the terminal states of the jobs queried and billed
+	JobStates []types.JobState `yaml:"job_states"`
+
+	ServiceUnitRates []types.ServiceUnitRule `yaml:"service_unit_rates"`
+	// ChargeModel is how a job's CPUs are counted for its CPU hours and
+	// service units, cpus or effective_cores
+	ChargeModel string `yaml:"charge_model"`
+}
+
+func Default() *Config {
+	return &Config{
+		SlurmBinDir:    "/gpfs/t2/slurm/apps/current/bin",
+		GpfsBinDir:     "/usr/lpp/mmfs/bin",
+		GpfsFilesystem: "fs1",
+		ProjectsDir:    "/gpfs/projects",
+		OpenUsePartitions: []string{
+			"compute",
+			"compute_intel",
+			"computelong",
+			"computelong_intel",
+			"gpu",
+			"gpulong",
+			"interactive",
+			"interactivegpu",
+			"memory",
+			"memorylong",
+		},
+		PreemptPartition: "preempt",
+		NodePrecedence:   types.NodePrecedenceCondo,
+		JobStates:        types.TerminalJobStates(),
+		ChargeModel:      types.ChargeModelCPUs,
+		// Open-Use job on high memory nodes:  1CpuHour == 2 SU, 1GpuHour == 3 SU
+		// Open-Use job on standard nodes:     1CpuHour == 1 SU, 1GpuHour == 3 SU
+		// Condo job:                          0 SU
+		// Preempt job:                        (CpuHours*1 + GpuHours*3) SU
+		ServiceUnitRates: []types.ServiceUnitRule{
+			{
+				Category:   types.JobCategoryOpen,
+				Partitions: []string{"memory", "memorylong"},
+				CPURate:    2,
+				GPURate:    3,
+			},
+			{
+				Category: types.JobCategoryOpen,
+				CPURate:  1,
+				GPURate:  3,
+			},
+			{
+				Category: types.JobCategoryCondo,
+			},
+			{
+				Category: types.JobCategoryPreempt,
+				Hours:    types.ServiceUnitHoursTotal,
+				CPURate:  1,
+				GPURate:  3,
+			},
+		},
+	}
+}
+
+// Load returns the defaults overlaid with the config file at path, if any,
+// and then the environment.
+func Load(path string) (*Config, error) {
+	c := Default()
+	if path != "" {
+		slog.Debug(fmt.Sprintf("  Loading config file: %s", path))
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		if err := yaml.Unmarshal(b, c); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %v", err)
+		}
+	}
+	if err := c.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) applyEnv() error {
+	for name, dst := range map[string]*string{
+		"SLURM_BIN_DIR":     &c.SlurmBinDir,
+		"GPFS_BIN_DIR":      &c.GpfsBinDir,
+		"GPFS_FILESYSTEM":   &c.GpfsFilesystem,
+		"PROJECTS_DIR":      &c.ProjectsDir,
+		"PREEMPT_PARTITION": &c.PreemptPartition,
+		"CHARGE_MODEL":      &c.ChargeModel,
+		"NODE_PRECEDENCE":   &c.NodePrecedence,
+	} {
+		if v, ok := os.LookupEnv(EnvPrefix + name); ok {
+			*dst = v
+		}
+	}
+	if v, ok := os.LookupEnv(EnvPrefix + "OPEN_USE_PARTITIONS"); ok {
+		c.OpenUsePartitions = SplitList(v)
+	}
+	if v, ok := os.LookupEnv(EnvPrefix + "JOB_STATES"); ok {
+		c.JobStates = []types.JobState{}
+		for _, s := range SplitList(v) {
+			c.JobStates = append(c.JobStates, types.JobState(s))
+		}
+	}
+	for name, dst := range map[string]*bool{
+		"SCONTROL_FALLBACK":   &c.ScontrolFallback,
+		"SCONTROL_JOB_DETAIL": &c.ScontrolJobDetail,
+	} {
+		if v, ok := os.LookupEnv(EnvPrefix + name); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s%s: %v", EnvPrefix, name, err)
+			}
+			*dst = b
+		}
+	}
+	return nil
+}
+
+func (c *Config) validate() error {
+	if len(c.JobStates) == 0 {
+		return fmt.Errorf("job_states must include at least one state")
+	}
+	for _, s := range c.JobStates {
+		if !slices.Contains(types.TerminalJobStates(), s) {
+			return fmt.Errorf("unknown job state: %s", s)
+		}
+	}
+	if !slices.Contains([]string{types.NodePrecedenceOpenUse, types.NodePrecedenceCondo, types.NodePrecedenceShared}, c.NodePrecedence) {
+		return fmt.Errorf("unknown node precedence: %s", c.NodePrecedence)
+	}
+	if c.ChargeModel != types.ChargeModelCPUs && c.ChargeModel != types.ChargeModelEffectiveCores {
+		return fmt.Errorf("unknown charge model: %s", c.ChargeModel)
+	}
+	return nil
+}
+
+// SplitList splits a comma separated value, dropping empty entries.
+func SplitList(v string) []string {
+	out := []string{}
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// State records which days have been processed successfully, so a restart
+// can pick up any days that were missed.
+type State struct {
+	path      string
+	processed map[string]bool
+}
+
+type stateFile struct {
+	Processed []string `json:"processed"`
+}
+
+// LoadState reads the state file at path, starting empty if it doesn't exist.
+func LoadState(path string) (*State, error) {
+	s := &State{
+		path:      path,
+		processed: make(map[string]bool),
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %v", err)
+	}
+	var sf stateFile
+	if err := json.Unmarshal(b, &sf); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %v", err)
+	}
+	for _, d := range sf.Processed {
+		s.processed[d] = true
+	}
+	return s, nil
+}
+
+// Mark records days as processed and saves the state file.
+func (s *State) Mark(days ...string) error {
+	if len(days) == 0 {
+		return nil
+	}
+	for _, d := range days {
+		s.processed[d] = true
+	}
+	sf := stateFile{Processed: []string{}}
+	for d := range s.processed {
+		sf.Processed = append(sf.Processed, d)
+	}
+	slices.Sort(sf.Processed)
+	b, err := json.MarshalIndent(sf, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("failed to write state file: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write state file: %v", err)
+	}
+	return nil
+}
+
+// Earliest returns the first processed day, or "" if there are none.
+func (s *State) Earliest() string {
+	earliest := ""
+	for d := range s.processed {
+		if earliest == "" || d < earliest {
+			earliest = d
+		}
+	}
+	return earliest
+}
+
+// Pending returns the days from from through through, inclusive, that
+// haven't been processed.
+func (s *State) Pending(from, through string) ([]string, error) {
+	f, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse day: %s", from)
+	}
+	t, err := time.Parse("2006-01-02", through)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse day: %s", through)
+	}
+	pending := []string{}
+	for d := f; !d.After(t); d = d.AddDate(0, 0, 1) {
+		if day := d.Format("2006-01-02"); !s.processed[day] {
+			pending = append(pending, day)
+		}
+	}
+	return pending, nil
+}
+
+// LastReadyDay is the most recent day that has finished, allowing delay
+// after midnight for slurm to settle.
+func LastReadyDay(now time.Time, delay time.Duration) string {
+	return now.Add(-delay).AddDate(0, 0, -1).Format("2006-01-02")
+}
+
+// NextRun is the next midnight after now, plus delay.
+func NextRun(now time.Time, delay time.Duration) time.Time {
+	y, m, d := now.Add(-delay).Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()).Add(delay)
+}
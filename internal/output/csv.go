@@ -0,0 +1,92 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+type csvJobWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVJobWriter(w io.Writer, header bool) (*csvJobWriter, error) {
+	cw := csv.NewWriter(w)
+	if header {
+		if err := cw.Write(system.JobKeys()); err != nil {
+			return nil, fmt.Errorf("failed to write header: %v", err)
+		}
+	}
+	return &csvJobWriter{
+		writer: cw,
+	}, nil
+}
+
+func (c *csvJobWriter) Write(j *system.Job) error {
+	return c.writer.Write(j.Fields())
+}
+
+func (c *csvJobWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// stepsJobWriter writes a csv row for each step of the jobs it's given.
+type stepsJobWriter struct {
+	writer *csv.Writer
+}
+
+func newStepsJobWriter(w io.Writer, header bool) (*stepsJobWriter, error) {
+	cw := csv.NewWriter(w)
+	if header {
+		if err := cw.Write(system.JobStepKeys()); err != nil {
+			return nil, fmt.Errorf("failed to write header: %v", err)
+		}
+	}
+	return &stepsJobWriter{
+		writer: cw,
+	}, nil
+}
+
+func (s *stepsJobWriter) Write(j *system.Job) error {
+	for _, step := range j.Steps {
+		if err := s.writer.Write(step.Fields()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stepsJobWriter) Close() error {
+	s.writer.Flush()
+	return s.writer.Error()
+}
+
+// gpuHoursJobWriter writes a csv row for each GPU model of the jobs it's
+// given.
+type gpuHoursJobWriter struct {
+	writer *csv.Writer
+}
+
+func newGPUHoursJobWriter(w io.Writer, header bool) (*gpuHoursJobWriter, error) {
+	cw := csv.NewWriter(w)
+	if header {
+		if err := cw.Write(system.JobGPUHoursKeys()); err != nil {
+			return nil, fmt.Errorf("failed to write header: %v", err)
+		}
+	}
+	return &gpuHoursJobWriter{
+		writer: cw,
+	}, nil
+}
+
+func (g *gpuHoursJobWriter) Write(j *system.Job) error {
+	return g.writer.WriteAll(j.GPUHoursFields())
+}
+
+func (g *gpuHoursJobWriter) Close() error {
+	g.writer.Flush()
+	return g.writer.Error()
+}
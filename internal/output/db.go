@@ -0,0 +1,342 @@
+package output
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+// dbColumns are the columns of the jobs table, in the order dbValues
+// returns them. job_id and date make up the primary key.
+var dbColumns = []struct {
+	name string
+	typ  string
+}{
+	{"job_id", "TEXT NOT NULL"},
+	{"job_name", "TEXT"},
+	{"username", "TEXT"},
+	{"account", "TEXT"},
+	{"partition", "TEXT"},
+	{"elapsed", "TEXT"},
+	{"node_count", "BIGINT"},
+	{"cpus", "BIGINT"},
+	{"tres", "TEXT"},
+	{"submit_time", "TEXT"},
+	{"start_time", "TEXT"},
+	{"end_time", "TEXT"},
+	{"node_list", "TEXT"},
+	{"state", "TEXT"},
+	{"pi_username", "TEXT"},
+	{"pi_full_name", "TEXT"},
+	{"account_storage_gb", "BIGINT"},
+	{"category", "TEXT"},
+	{"openuse_weight", "DOUBLE PRECISION"},
+	{"condo_weight", "DOUBLE PRECISION"},
+	{"gpus", "BIGINT"},
+	{"cpu_hours_openuse", "DOUBLE PRECISION"},
+	{"cpu_hours_condo", "DOUBLE PRECISION"},
+	{"cpu_hours_total", "DOUBLE PRECISION"},
+	{"gpu_hours_openuse", "DOUBLE PRECISION"},
+	{"gpu_hours_condo", "DOUBLE PRECISION"},
+	{"gpu_hours_total", "DOUBLE PRECISION"},
+	{"wait_time_hours", "DOUBLE PRECISION"},
+	{"run_time_hours", "DOUBLE PRECISION"},
+	{"date", "TEXT NOT NULL"},
+	{"user_full_name", "TEXT"},
+	{"service_units", "DOUBLE PRECISION"},
+	{"qos", "TEXT"},
+	{"prorated_run_time_hours", "DOUBLE PRECISION"},
+	{"full_cpu_hours_total", "DOUBLE PRECISION"},
+	{"full_gpu_hours_total", "DOUBLE PRECISION"},
+	{"step_count", "BIGINT"},
+	{"step_cpu_hours", "DOUBLE PRECISION"},
+	{"step_gpu_hours", "DOUBLE PRECISION"},
+	{"mem_gb", "DOUBLE PRECISION"},
+	{"billing_tres", "BIGINT"},
+	{"gpu_type", "TEXT"},
+	{"mem_gb_hours", "DOUBLE PRECISION"},
+	{"effective_cpus", "BIGINT"},
+}
+
+func dbValues(j *system.Job) []any {
+	return []any{
+		j.JobID,
+		j.JobName,
+		j.Username,
+		j.Account,
+		j.Partition,
+		j.Elapsed,
+		j.NodeCount,
+		j.CPUs,
+		j.TRES,
+		j.SubmitTime,
+		j.StartTime,
+		j.EndTime,
+		j.NodeList,
+		string(j.State),
+		j.PIUsername,
+		j.PIFullName,
+		j.AccountStorageGB,
+		string(j.Category),
+		j.OpenuseWeight,
+		j.CondoWeight,
+		j.GPUs,
+		j.CPUHoursOpenUse,
+		j.CPUHoursCondo,
+		j.CPUHoursTotal,
+		j.GPUHoursOpenUse,
+		j.GPUHoursCondo,
+		j.GPUHoursTotal,
+		j.WaitTimeHours,
+		j.RunTimeHours,
+		j.Date,
+		j.UserFullName,
+		j.ServiceUnits,
+		j.QOS,
+		j.ProratedRunTimeHours,
+		j.FullCPUHoursTotal,
+		j.FullGPUHoursTotal,
+		j.StepCount,
+		j.StepCPUHours,
+		j.StepGPUHours,
+		j.MemGB,
+		j.BillingTRES,
+		j.GPUType,
+		j.MemGBHours,
+		j.EffectiveCPUs,
+	}
+}
+
+// createGPUHoursTable is the job_gpu_hours table, which has the GPU hours of
+// each job by GPU model.
+const createGPUHoursTable = `CREATE TABLE IF NOT EXISTS job_gpu_hours (
+	job_id TEXT NOT NULL,
+	date TEXT NOT NULL,
+	gpu_type TEXT NOT NULL,
+	gpu_hours DOUBLE PRECISION,
+	PRIMARY KEY (job_id, date, gpu_type)
+)`
+
+const upsertGPUHours = `INSERT INTO job_gpu_hours (job_id, date, gpu_type, gpu_hours) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (job_id, date, gpu_type) DO UPDATE SET gpu_hours = excluded.gpu_hours`
+
+// DB writes jobs to a SQLite or PostgreSQL jobs table, and their GPU hours by
+// model to the job_gpu_hours table. Each day is written in a single
+// transaction that first removes the day's existing rows, so reprocessing a
+// day replaces it atomically. If any job of the day fails to insert the
+// transaction is rolled back, keeping the previous rows.
+type DB struct {
+	db             *sql.DB
+	tx             *sql.Tx
+	insert         *sql.Stmt
+	insertGPUHours *sql.Stmt
+	day            string
+	writeErr       error
+}
+
+// NewDB opens the database described by dsn and creates the jobs table if
+// needed. dsn is either sqlite:<path> or a postgres:// URL.
+func NewDB(dsn string) (*DB, error) {
+	driver, source, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug(fmt.Sprintf("  Opening %s database", driver))
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	if _, err := db.Exec(createJobsTable()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create jobs table: %v", err)
+	}
+	if _, err := db.Exec(createGPUHoursTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create job_gpu_hours table: %v", err)
+	}
+	if err := migrateJobsTable(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &DB{
+		db: db,
+	}, nil
+}
+
+func parseDSN(dsn string) (string, string, error) {
+	if path, ok := strings.CutPrefix(dsn, "sqlite:"); ok {
+		return "sqlite", path, nil
+	}
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return "pgx", dsn, nil
+	}
+	return "", "", fmt.Errorf("database must be sqlite:<path> or a postgres:// url: %s", dsn)
+}
+
+func createJobsTable() string {
+	cols := []string{}
+	for _, c := range dbColumns {
+		cols = append(cols, fmt.Sprintf("%s %s", c.name, c.typ))
+	}
+	cols = append(cols, "PRIMARY KEY (job_id, date)")
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS jobs (%s)", strings.Join(cols, ", "))
+}
+
+// migrateJobsTable adds any columns that a jobs table created by an older
+// version is missing.
+func migrateJobsTable(db *sql.DB) error {
+	rows, err := db.Query("SELECT * FROM jobs LIMIT 0")
+	if err != nil {
+		return fmt.Errorf("failed to read jobs table: %v", err)
+	}
+	existing, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read jobs table columns: %v", err)
+	}
+	for _, c := range dbColumns {
+		if slices.Contains(existing, c.name) {
+			continue
+		}
+		slog.Debug(fmt.Sprintf("    Adding column to jobs table: %s", c.name))
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE jobs ADD COLUMN %s %s", c.name, c.typ)); err != nil {
+			return fmt.Errorf("failed to add column %s: %v", c.name, err)
+		}
+	}
+	return nil
+}
+
+func upsertJob() string {
+	names := []string{}
+	params := []string{}
+	updates := []string{}
+	for i, c := range dbColumns {
+		names = append(names, c.name)
+		params = append(params, fmt.Sprintf("$%d", i+1))
+		if c.name != "job_id" && c.name != "date" {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", c.name, c.name))
+		}
+	}
+	return fmt.Sprintf(
+		"INSERT INTO jobs (%s) VALUES (%s) ON CONFLICT (job_id, date) DO UPDATE SET %s",
+		strings.Join(names, ", "),
+		strings.Join(params, ", "),
+		strings.Join(updates, ", "),
+	)
+}
+
+func (d *DB) Day(day string) (JobWriter, error) {
+	if d.tx != nil && d.day == day {
+		return &dbJobWriter{d}, nil
+	}
+	if err := d.EndDay(); err != nil {
+		return nil, err
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	if _, err := tx.Exec("DELETE FROM jobs WHERE date = $1", day); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to clear jobs for %s: %v", day, err)
+	}
+	if _, err := tx.Exec("DELETE FROM job_gpu_hours WHERE date = $1", day); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to clear gpu hours for %s: %v", day, err)
+	}
+	insert, err := tx.Prepare(upsertJob())
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to prepare insert: %v", err)
+	}
+	insertGPUHours, err := tx.Prepare(upsertGPUHours)
+	if err != nil {
+		insert.Close()
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to prepare gpu hours insert: %v", err)
+	}
+	d.tx = tx
+	d.insert = insert
+	d.insertGPUHours = insertGPUHours
+	d.day = day
+	return &dbJobWriter{d}, nil
+}
+
+// Close commits the open day and closes the database.
+func (d *DB) Close() error {
+	if err := d.EndDay(); err != nil {
+		return err
+	}
+	return d.db.Close()
+}
+
+// EndDay commits the open day, if any, or rolls it back when a job failed to
+// insert.
+func (d *DB) EndDay() error {
+	if d.tx == nil {
+		return nil
+	}
+	d.insert.Close()
+	d.insertGPUHours.Close()
+	writeErr := d.writeErr
+	var err error
+	if writeErr != nil {
+		err = d.tx.Rollback()
+	} else {
+		err = d.tx.Commit()
+	}
+	d.tx = nil
+	d.insert = nil
+	d.insertGPUHours = nil
+	d.writeErr = nil
+	if writeErr != nil {
+		if err != nil {
+			return fmt.Errorf("failed to roll back jobs for %s after write failed: %v: %v", d.day, writeErr, err)
+		}
+		return fmt.Errorf("rolled back jobs for %s after write failed: %v", d.day, writeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to commit jobs for %s: %v", d.day, err)
+	}
+	slog.Debug(fmt.Sprintf("  Committed jobs for %s", d.day))
+	return nil
+}
+
+type dbJobWriter struct {
+	db *DB
+}
+
+func (w *dbJobWriter) Write(j *system.Job) error {
+	if w.db.tx == nil {
+		return fmt.Errorf("no open transaction")
+	}
+	err := w.insert(j)
+	if err != nil && w.db.writeErr == nil {
+		w.db.writeErr = err
+	}
+	return err
+}
+
+func (w *dbJobWriter) insert(j *system.Job) error {
+	if _, err := w.db.insert.Exec(dbValues(j)...); err != nil {
+		return fmt.Errorf("failed to insert job %s: %v", j.JobID, err)
+	}
+	for model, hours := range j.GPUHoursByType {
+		if _, err := w.db.insertGPUHours.Exec(j.JobID, j.Date, model, hours); err != nil {
+			return fmt.Errorf("failed to insert gpu hours for job %s: %v", j.JobID, err)
+		}
+	}
+	return nil
+}
+
+// Close is a no-op, the day is committed by DB.EndDay.
+func (w *dbJobWriter) Close() error {
+	return nil
+}
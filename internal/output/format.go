@@ -0,0 +1,51 @@
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+	FormatJSONL   = "jsonl"
+	// FormatSteps writes the step rows of each job as csv, used for the
+	// step mode output rather than chosen by the user
+	FormatSteps = "steps"
+	// FormatSummary writes per account, PI, partition and category totals
+	FormatSummary = "summary"
+	// FormatGPUHours writes the GPU hours of each job by GPU model as csv
+	FormatGPUHours = "gpu-hours"
+)
+
+// JobWriter writes processed jobs in a single output format. Close flushes
+// anything buffered but leaves the underlying writer open.
+type JobWriter interface {
+	Write(j *system.Job) error
+	Close() error
+}
+
+// Formats lists the supported values for the output format.
+func Formats() []string {
+	return []string{FormatCSV, FormatParquet, FormatJSONL}
+}
+
+func newJobWriter(format string, w io.Writer, header bool) (JobWriter, error) {
+	switch format {
+	case "", FormatCSV:
+		return newCSVJobWriter(w, header)
+	case FormatParquet:
+		return newParquetJobWriter(w), nil
+	case FormatJSONL:
+		return newJSONLJobWriter(w), nil
+	case FormatSteps:
+		return newStepsJobWriter(w, header)
+	case FormatSummary:
+		return newSummaryJobWriter(w, header), nil
+	case FormatGPUHours:
+		return newGPUHoursJobWriter(w, header)
+	}
+	return nil, fmt.Errorf("unknown output format: %s", format)
+}
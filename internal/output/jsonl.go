@@ -0,0 +1,127 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+// jsonJob is the JSON Lines representation of a Job. Keys match
+// system.JobKeys, times are RFC3339 and are null when slurm didn't set them.
+type jsonJob struct {
+	JobID                string             `json:"JobID"`
+	JobName              string             `json:"JobName"`
+	Username             string             `json:"Username"`
+	Account              string             `json:"Account"`
+	Partition            string             `json:"Partition"`
+	Elapsed              string             `json:"Elapsed"`
+	NodeCount            int                `json:"NodeCount"`
+	CPUs                 int                `json:"CPUs"`
+	TRES                 string             `json:"TRES"`
+	SubmitTime           *time.Time         `json:"SubmitTime"`
+	StartTime            *time.Time         `json:"StartTime"`
+	EndTime              *time.Time         `json:"EndTime"`
+	NodeList             string             `json:"NodeList"`
+	State                string             `json:"State"`
+	PIUsername           string             `json:"PIUsername"`
+	PIFullName           string             `json:"PIFullName"`
+	AccountStorageGB     int                `json:"AccountStorageGB"`
+	Category             string             `json:"Category"`
+	OpenuseWeight        float64            `json:"OpenuseWeight"`
+	CondoWeight          float64            `json:"CondoWeight"`
+	GPUs                 int                `json:"GPUs"`
+	CPUHoursOpenUse      float64            `json:"CPUHoursOpenUse"`
+	CPUHoursCondo        float64            `json:"CPUHoursCondo"`
+	CPUHoursTotal        float64            `json:"CPUHoursTotal"`
+	GPUHoursOpenUse      float64            `json:"GPUHoursOpenUse"`
+	GPUHoursCondo        float64            `json:"GPUHoursCondo"`
+	GPUHoursTotal        float64            `json:"GPUHoursTotal"`
+	WaitTimeHours        float64            `json:"WaitTimeHours"`
+	RunTimeHours         float64            `json:"RunTimeHours"`
+	Date                 string             `json:"Date"`
+	UserFullName         string             `json:"UserFullName"`
+	ServiceUnits         float64            `json:"ServiceUnits"`
+	QOS                  string             `json:"QOS"`
+	ProratedRunTimeHours float64            `json:"ProratedRunTimeHours"`
+	FullCPUHoursTotal    float64            `json:"FullCPUHoursTotal"`
+	FullGPUHoursTotal    float64            `json:"FullGPUHoursTotal"`
+	StepCount            int                `json:"StepCount"`
+	StepCPUHours         float64            `json:"StepCPUHours"`
+	StepGPUHours         float64            `json:"StepGPUHours"`
+	MemGB                float64            `json:"MemGB"`
+	BillingTRES          int                `json:"BillingTRES"`
+	GPUType              string             `json:"GPUType"`
+	GPUHoursByType       map[string]float64 `json:"GPUHoursByType"`
+	MemGBHours           float64            `json:"MemGBHours"`
+	EffectiveCPUs        int                `json:"EffectiveCPUs"`
+}
+
+type jsonlJobWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newJSONLJobWriter(w io.Writer) *jsonlJobWriter {
+	buf := bufio.NewWriter(w)
+	return &jsonlJobWriter{
+		buf:     buf,
+		encoder: json.NewEncoder(buf),
+	}
+}
+
+func (jw *jsonlJobWriter) Write(j *system.Job) error {
+	return jw.encoder.Encode(jsonJob{
+		JobID:                j.JobID,
+		JobName:              j.JobName,
+		Username:             j.Username,
+		Account:              j.Account,
+		Partition:            j.Partition,
+		Elapsed:              j.Elapsed,
+		NodeCount:            j.NodeCount,
+		CPUs:                 j.CPUs,
+		TRES:                 j.TRES,
+		SubmitTime:           parseTimestamp("2006-01-02T15:04:05", j.SubmitTime, time.Local),
+		StartTime:            parseTimestamp("2006-01-02T15:04:05", j.StartTime, time.Local),
+		EndTime:              parseTimestamp("2006-01-02T15:04:05", j.EndTime, time.Local),
+		NodeList:             j.NodeList,
+		State:                string(j.State),
+		PIUsername:           j.PIUsername,
+		PIFullName:           j.PIFullName,
+		AccountStorageGB:     j.AccountStorageGB,
+		Category:             string(j.Category),
+		OpenuseWeight:        j.OpenuseWeight,
+		CondoWeight:          j.CondoWeight,
+		GPUs:                 j.GPUs,
+		CPUHoursOpenUse:      j.CPUHoursOpenUse,
+		CPUHoursCondo:        j.CPUHoursCondo,
+		CPUHoursTotal:        j.CPUHoursTotal,
+		GPUHoursOpenUse:      j.GPUHoursOpenUse,
+		GPUHoursCondo:        j.GPUHoursCondo,
+		GPUHoursTotal:        j.GPUHoursTotal,
+		WaitTimeHours:        j.WaitTimeHours,
+		RunTimeHours:         j.RunTimeHours,
+		Date:                 j.Date,
+		UserFullName:         j.UserFullName,
+		ServiceUnits:         j.ServiceUnits,
+		QOS:                  j.QOS,
+		ProratedRunTimeHours: j.ProratedRunTimeHours,
+		FullCPUHoursTotal:    j.FullCPUHoursTotal,
+		FullGPUHoursTotal:    j.FullGPUHoursTotal,
+		StepCount:            j.StepCount,
+		StepCPUHours:         j.StepCPUHours,
+		StepGPUHours:         j.StepGPUHours,
+		MemGB:                j.MemGB,
+		BillingTRES:          j.BillingTRES,
+		GPUType:              j.GPUType,
+		GPUHoursByType:       j.GPUHoursByType,
+		MemGBHours:           j.MemGBHours,
+		EffectiveCPUs:        j.EffectiveCPUs,
+	})
+}
+
+func (jw *jsonlJobWriter) Close() error {
+	return jw.buf.Flush()
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"errors"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+// Output receives the processed jobs one day at a time. Day is called before
+// any of the day's jobs are written and EndDay once they all have been.
+type Output interface {
+	Day(day string) (JobWriter, error)
+	EndDay() error
+	Close() error
+}
+
+// DayFinisher is implemented by outputs that should only keep a day once the
+// whole day succeeded, not just once its jobs were written, like the
+// allocation ledger. FinishDay is called after EndDay with the day's result.
+type DayFinisher interface {
+	FinishDay(ok bool)
+}
+
+type multiOutput struct {
+	outputs []Output
+	closed  bool
+}
+
+// NewMulti returns an Output that writes every job to each of outputs.
+func NewMulti(outputs ...Output) Output {
+	return &multiOutput{
+		outputs: outputs,
+	}
+}
+
+func (m *multiOutput) Day(day string) (JobWriter, error) {
+	writers := []JobWriter{}
+	for _, o := range m.outputs {
+		w, err := o.Day(day)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, w)
+	}
+	return &multiJobWriter{
+		writers: writers,
+	}, nil
+}
+
+func (m *multiOutput) EndDay() error {
+	var errs []error
+	for _, o := range m.outputs {
+		errs = append(errs, o.EndDay())
+	}
+	return errors.Join(errs...)
+}
+
+func (m *multiOutput) FinishDay(ok bool) {
+	for _, o := range m.outputs {
+		if f, isFinisher := o.(DayFinisher); isFinisher {
+			f.FinishDay(ok)
+		}
+	}
+}
+
+// Close closes every output once, later calls are no-ops.
+func (m *multiOutput) Close() error {
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	var errs []error
+	for _, o := range m.outputs {
+		errs = append(errs, o.Close())
+	}
+	return errors.Join(errs...)
+}
+
+type multiJobWriter struct {
+	writers []JobWriter
+}
+
+func (m *multiJobWriter) Write(j *system.Job) error {
+	var errs []error
+	for _, w := range m.writers {
+		errs = append(errs, w.Write(j))
+	}
+	return errors.Join(errs...)
+}
+
+// Close is a no-op, the underlying writers are closed by their Output.
+func (m *multiJobWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,132 @@
+package output
+
+import (
+	"io"
+	"time"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetJob is the typed column layout of a Job. Column names match
+// system.JobKeys so the csv and parquet outputs line up.
+type parquetJob struct {
+	JobID                string     `parquet:"JobID"`
+	JobName              string     `parquet:"JobName"`
+	Username             string     `parquet:"Username,dict"`
+	Account              string     `parquet:"Account,dict"`
+	Partition            string     `parquet:"Partition,dict"`
+	Elapsed              string     `parquet:"Elapsed"`
+	NodeCount            int64      `parquet:"NodeCount"`
+	CPUs                 int64      `parquet:"CPUs"`
+	TRES                 string     `parquet:"TRES"`
+	SubmitTime           *time.Time `parquet:"SubmitTime,optional,timestamp(millisecond)"`
+	StartTime            *time.Time `parquet:"StartTime,optional,timestamp(millisecond)"`
+	EndTime              *time.Time `parquet:"EndTime,optional,timestamp(millisecond)"`
+	NodeList             string     `parquet:"NodeList"`
+	State                string     `parquet:"State,dict"`
+	PIUsername           string     `parquet:"PIUsername,dict"`
+	PIFullName           string     `parquet:"PIFullName,dict"`
+	AccountStorageGB     int64      `parquet:"AccountStorageGB"`
+	Category             string     `parquet:"Category,dict"`
+	OpenuseWeight        float64    `parquet:"OpenuseWeight"`
+	CondoWeight          float64    `parquet:"CondoWeight"`
+	GPUs                 int64      `parquet:"GPUs"`
+	CPUHoursOpenUse      float64    `parquet:"CPUHoursOpenUse"`
+	CPUHoursCondo        float64    `parquet:"CPUHoursCondo"`
+	CPUHoursTotal        float64    `parquet:"CPUHoursTotal"`
+	GPUHoursOpenUse      float64    `parquet:"GPUHoursOpenUse"`
+	GPUHoursCondo        float64    `parquet:"GPUHoursCondo"`
+	GPUHoursTotal        float64    `parquet:"GPUHoursTotal"`
+	WaitTimeHours        float64    `parquet:"WaitTimeHours"`
+	RunTimeHours         float64    `parquet:"RunTimeHours"`
+	Date                 *time.Time `parquet:"Date,optional,date"`
+	UserFullName         string     `parquet:"UserFullName"`
+	ServiceUnits         float64    `parquet:"ServiceUnits"`
+	QOS                  string     `parquet:"QOS,dict"`
+	ProratedRunTimeHours float64    `parquet:"ProratedRunTimeHours"`
+	FullCPUHoursTotal    float64    `parquet:"FullCPUHoursTotal"`
+	FullGPUHoursTotal    float64    `parquet:"FullGPUHoursTotal"`
+	StepCount            int64      `parquet:"StepCount"`
+	StepCPUHours         float64    `parquet:"StepCPUHours"`
+	StepGPUHours         float64    `parquet:"StepGPUHours"`
+	MemGB                float64    `parquet:"MemGB"`
+	BillingTRES          int64      `parquet:"BillingTRES"`
+	GPUType              string     `parquet:"GPUType,dict"`
+	MemGBHours           float64    `parquet:"MemGBHours"`
+	EffectiveCPUs        int64      `parquet:"EffectiveCPUs"`
+}
+
+type parquetJobWriter struct {
+	writer *parquet.GenericWriter[parquetJob]
+}
+
+func newParquetJobWriter(w io.Writer) *parquetJobWriter {
+	return &parquetJobWriter{
+		writer: parquet.NewGenericWriter[parquetJob](w),
+	}
+}
+
+func (p *parquetJobWriter) Write(j *system.Job) error {
+	_, err := p.writer.Write([]parquetJob{{
+		JobID:                j.JobID,
+		JobName:              j.JobName,
+		Username:             j.Username,
+		Account:              j.Account,
+		Partition:            j.Partition,
+		Elapsed:              j.Elapsed,
+		NodeCount:            int64(j.NodeCount),
+		CPUs:                 int64(j.CPUs),
+		TRES:                 j.TRES,
+		SubmitTime:           parseTimestamp("2006-01-02T15:04:05", j.SubmitTime, time.Local),
+		StartTime:            parseTimestamp("2006-01-02T15:04:05", j.StartTime, time.Local),
+		EndTime:              parseTimestamp("2006-01-02T15:04:05", j.EndTime, time.Local),
+		NodeList:             j.NodeList,
+		State:                string(j.State),
+		PIUsername:           j.PIUsername,
+		PIFullName:           j.PIFullName,
+		AccountStorageGB:     int64(j.AccountStorageGB),
+		Category:             string(j.Category),
+		OpenuseWeight:        j.OpenuseWeight,
+		CondoWeight:          j.CondoWeight,
+		GPUs:                 int64(j.GPUs),
+		CPUHoursOpenUse:      j.CPUHoursOpenUse,
+		CPUHoursCondo:        j.CPUHoursCondo,
+		CPUHoursTotal:        j.CPUHoursTotal,
+		GPUHoursOpenUse:      j.GPUHoursOpenUse,
+		GPUHoursCondo:        j.GPUHoursCondo,
+		GPUHoursTotal:        j.GPUHoursTotal,
+		WaitTimeHours:        j.WaitTimeHours,
+		RunTimeHours:         j.RunTimeHours,
+		Date:                 parseTimestamp("2006-01-02", j.Date, time.UTC),
+		UserFullName:         j.UserFullName,
+		ServiceUnits:         j.ServiceUnits,
+		QOS:                  j.QOS,
+		ProratedRunTimeHours: j.ProratedRunTimeHours,
+		FullCPUHoursTotal:    j.FullCPUHoursTotal,
+		FullGPUHoursTotal:    j.FullGPUHoursTotal,
+		StepCount:            int64(j.StepCount),
+		StepCPUHours:         j.StepCPUHours,
+		StepGPUHours:         j.StepGPUHours,
+		MemGB:                j.MemGB,
+		BillingTRES:          int64(j.BillingTRES),
+		GPUType:              j.GPUType,
+		MemGBHours:           j.MemGBHours,
+		EffectiveCPUs:        int64(j.EffectiveCPUs),
+	}})
+	return err
+}
+
+func (p *parquetJobWriter) Close() error {
+	return p.writer.Close()
+}
+
+// parseTimestamp returns nil for values slurm leaves as Unknown or None.
+// Slurm reports times in the cluster's local time zone.
+func parseTimestamp(layout, value string, loc *time.Location) *time.Time {
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
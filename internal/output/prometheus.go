@@ -0,0 +1,136 @@
+package output
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "process_job_stats"
+
+// Prometheus exposes the usage of the most recently processed day as gauges
+// per account, partition and category, along with pipeline counters for the
+// whole run. The metrics are written to a node_exporter textfile collector
+// file after each day and can also be served from Registry.
+type Prometheus struct {
+	path     string
+	registry *prometheus.Registry
+
+	jobs         *prometheus.GaugeVec
+	serviceUnits *prometheus.GaugeVec
+	cpuHours     *prometheus.GaugeVec
+	gpuHours     *prometheus.GaugeVec
+	day          prometheus.Gauge
+}
+
+// NewPrometheus returns the metrics output. path may be empty when the
+// metrics are only served.
+func NewPrometheus(path string, stats *system.RunStats) *Prometheus {
+	labels := []string{"account", "partition", "category"}
+	p := &Prometheus{
+		path:     path,
+		registry: prometheus.NewRegistry(),
+		jobs: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "jobs",
+			Help:      "Jobs on the processed day.",
+		}, labels),
+		serviceUnits: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "service_units",
+			Help:      "Service units consumed on the processed day.",
+		}, labels),
+		cpuHours: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cpu_hours",
+			Help:      "CPU hours used on the processed day.",
+		}, labels),
+		gpuHours: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "gpu_hours",
+			Help:      "GPU hours used on the processed day.",
+		}, labels),
+		day: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "processed_day_timestamp_seconds",
+			Help:      "Start of the day the usage metrics describe, as a unix timestamp.",
+		}),
+	}
+	p.registry.MustRegister(p.jobs, p.serviceUnits, p.cpuHours, p.gpuHours, p.day)
+
+	for result, counter := range map[string]func() int64{
+		"processed": stats.Processed.Load,
+		"skipped":   stats.Skipped.Load,
+		"failed":    stats.Failed.Load,
+	} {
+		p.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "pipeline_jobs_total",
+			Help:        "Job records handled by the workers this run, by result.",
+			ConstLabels: prometheus.Labels{"result": result},
+		}, func() float64 { return float64(counter()) }))
+	}
+	return p
+}
+
+// Registry is used to serve the metrics over HTTP.
+func (p *Prometheus) Registry() *prometheus.Registry {
+	return p.registry
+}
+
+// Day resets the usage gauges, even for the day already shown, since the
+// daemon retries a failed day by processing all of its jobs again.
+func (p *Prometheus) Day(day string) (JobWriter, error) {
+	d, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse day for metrics: %v", err)
+	}
+	p.jobs.Reset()
+	p.serviceUnits.Reset()
+	p.cpuHours.Reset()
+	p.gpuHours.Reset()
+	p.day.Set(float64(d.Unix()))
+	return &prometheusJobWriter{p}, nil
+}
+
+// EndDay writes the textfile, if configured.
+func (p *Prometheus) EndDay() error {
+	if p.path == "" {
+		return nil
+	}
+	if err := prometheus.WriteToTextfile(p.path, p.registry); err != nil {
+		return fmt.Errorf("failed to write metrics textfile: %v", err)
+	}
+	slog.Debug(fmt.Sprintf("  Wrote metrics textfile: %s", p.path))
+	return nil
+}
+
+// Close writes the final pipeline counters.
+func (p *Prometheus) Close() error {
+	return p.EndDay()
+}
+
+type prometheusJobWriter struct {
+	p *Prometheus
+}
+
+func (w *prometheusJobWriter) Write(j *system.Job) error {
+	labels := prometheus.Labels{
+		"account":   j.Account,
+		"partition": j.Partition,
+		"category":  string(j.Category),
+	}
+	w.p.jobs.With(labels).Inc()
+	w.p.serviceUnits.With(labels).Add(j.ServiceUnits)
+	w.p.cpuHours.With(labels).Add(j.CPUHoursTotal)
+	w.p.gpuHours.With(labels).Add(j.GPUHoursTotal)
+	return nil
+}
+
+// Close is a no-op, the textfile is written by Prometheus.EndDay.
+func (w *prometheusJobWriter) Close() error {
+	return nil
+}
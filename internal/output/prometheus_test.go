@@ -0,0 +1,53 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+func TestPrometheusDayRetry(t *testing.T) {
+	p := NewPrometheus("", system.NewRunStats())
+	j := &system.Job{
+		Account:       "hpc",
+		Partition:     "compute",
+		Category:      types.JobCategoryOpen,
+		ServiceUnits:  10,
+		CPUHoursTotal: 4,
+	}
+	// a retried day writes all of its jobs again
+	for range 2 {
+		w, err := p.Day("2025-01-02")
+		if err != nil {
+			t.Fatalf("Day error: %v", err)
+		}
+		if err := w.Write(j); err != nil {
+			t.Fatalf("Write error: %v", err)
+		}
+		if err := p.EndDay(); err != nil {
+			t.Fatalf("EndDay error: %v", err)
+		}
+	}
+	families, err := p.Registry().Gather()
+	if err != nil {
+		t.Fatalf("Gather error: %v", err)
+	}
+	want := map[string]float64{
+		metricsNamespace + "_jobs":          1,
+		metricsNamespace + "_service_units": 10,
+		metricsNamespace + "_cpu_hours":     4,
+	}
+	for _, mf := range families {
+		w, ok := want[mf.GetName()]
+		if !ok {
+			continue
+		}
+		if len(mf.GetMetric()) != 1 {
+			t.Fatalf("%s has %d series, want 1", mf.GetName(), len(mf.GetMetric()))
+		}
+		if got := mf.GetMetric()[0].GetGauge().GetValue(); got != w {
+			t.Errorf("%s = %f, want %f", mf.GetName(), got, w)
+		}
+	}
+}
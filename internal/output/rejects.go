@@ -0,0 +1,82 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+// Rejects writes the raw sacct records of jobs that failed processing along
+// with the stage that failed and the error, one csv row per record. The file
+// is appended to so that daemon runs keep the earlier days' rejects.
+type Rejects struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+func RejectKeys() []string {
+	return []string{
+		"Date",
+		"JobID",
+		"Stage",
+		"Error",
+		"Record",
+	}
+}
+
+func NewRejects(path string, header bool) (*Rejects, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create rejects directory: %v", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rejects file: %v", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat rejects file: %v", err)
+	}
+	r := &Rejects{
+		file:   f,
+		writer: csv.NewWriter(f),
+	}
+	if header && info.Size() == 0 {
+		if err := r.writer.Write(RejectKeys()); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Write records a rejected sacct record for the given day.
+func (r *Rejects) Write(day, record string, err error) error {
+	jobID, _, _ := strings.Cut(record, "|")
+	return r.writer.Write([]string{
+		day,
+		jobID,
+		string(system.JobErrorStage(err)),
+		err.Error(),
+		record,
+	})
+}
+
+func (r *Rejects) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	r.writer.Flush()
+	err := r.writer.Error()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	return err
+}
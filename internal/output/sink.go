@@ -0,0 +1,137 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// PathData is what an output path template is rendered with, e.g.
+// out/{{.Year}}/{{.Date}}.csv
+type PathData struct {
+	Date  string
+	Year  string
+	Month string
+	Day   string
+}
+
+// Sink hands out a JobWriter for each processed day. A plain path (or stdout
+// when empty) is opened once and shared by every day, a templated path gets
+// a new file per day.
+type Sink struct {
+	path     string
+	tmpl     *template.Template
+	format   string
+	header   bool
+	file     *os.File
+	writer   JobWriter
+	openPath string
+}
+
+func NewSink(path, format string, header bool) (*Sink, error) {
+	if !slices.Contains(append(Formats(), FormatSteps, FormatSummary, FormatGPUHours), format) {
+		return nil, fmt.Errorf("unknown output format: %s", format)
+	}
+	s := &Sink{
+		path:   path,
+		format: format,
+		header: header,
+	}
+	if strings.Contains(path, "{{") {
+		t, err := template.New("output").Option("missingkey=error").Parse(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse output path template: %v", err)
+		}
+		s.tmpl = t
+	}
+	return s, nil
+}
+
+// EndDay closes the day's file when each day is written to its own file.
+func (s *Sink) EndDay() error {
+	if s.tmpl == nil {
+		return nil
+	}
+	return s.Close()
+}
+
+// Day returns the writer for the given day, opening a new output if needed.
+func (s *Sink) Day(day string) (JobWriter, error) {
+	path, err := s.render(day)
+	if err != nil {
+		return nil, err
+	}
+	if s.writer != nil && path == s.openPath {
+		return s.writer, nil
+	}
+	if err := s.Close(); err != nil {
+		return nil, err
+	}
+
+	f := os.Stdout
+	if path != "" {
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create output directory: %v", err)
+			}
+		}
+		f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open output file: %v", err)
+		}
+	}
+	w, err := newJobWriter(s.format, f, s.header)
+	if err != nil {
+		if f != os.Stdout {
+			f.Close()
+		}
+		return nil, err
+	}
+	s.file = f
+	s.openPath = path
+	s.writer = w
+	return s.writer, nil
+}
+
+// Close flushes and closes the currently open output, if any.
+func (s *Sink) Close() error {
+	if s.writer == nil {
+		return nil
+	}
+	err := s.writer.Close()
+	if s.file != os.Stdout {
+		if cerr := s.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	s.writer = nil
+	s.file = nil
+	s.openPath = ""
+	return err
+}
+
+func (s *Sink) render(day string) (string, error) {
+	if s.tmpl == nil {
+		return s.path, nil
+	}
+	d, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse day for output path: %v", err)
+	}
+	var b bytes.Buffer
+	err = s.tmpl.Execute(&b, PathData{
+		Date:  day,
+		Year:  d.Format("2006"),
+		Month: d.Format("01"),
+		Day:   d.Format("02"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render output path: %v", err)
+	}
+	return b.String(), nil
+}
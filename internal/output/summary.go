@@ -0,0 +1,145 @@
+package output
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+// The dimensions that jobs are summarized by.
+const (
+	SummaryByAccount   = "account"
+	SummaryByPI        = "pi"
+	SummaryByPartition = "partition"
+	SummaryByCategory  = "category"
+	// only jobs with GPUs are summarized by GPU type, untyped ones as "untyped"
+	SummaryByGPUType = "gputype"
+)
+
+type summaryKey struct {
+	date  string
+	group string
+	key   string
+}
+
+type summaryRow struct {
+	jobs          int
+	states        map[types.JobState]int
+	cpuHours      float64
+	gpuHours      float64
+	serviceUnits  float64
+	waitTimeHours float64
+}
+
+// summaryJobWriter aggregates jobs per day by account, PI, partition and
+// category, writing the rows once the output is closed.
+type summaryJobWriter struct {
+	writer *csv.Writer
+	header bool
+	rows   map[summaryKey]*summaryRow
+}
+
+func newSummaryJobWriter(w io.Writer, header bool) *summaryJobWriter {
+	return &summaryJobWriter{
+		writer: csv.NewWriter(w),
+		header: header,
+		rows:   make(map[summaryKey]*summaryRow),
+	}
+}
+
+func SummaryKeys() []string {
+	keys := []string{
+		"Date",
+		"GroupBy",
+		"Key",
+		"Jobs",
+	}
+	for _, s := range types.JobStates() {
+		name := ""
+		for _, w := range strings.Split(string(s), "_") {
+			name += strings.ToUpper(w[:1]) + w[1:]
+		}
+		keys = append(keys, fmt.Sprintf("Jobs%s", name))
+	}
+	return append(keys,
+		"CPUHoursTotal",
+		"GPUHoursTotal",
+		"ServiceUnits",
+		"MeanWaitTimeHours",
+	)
+}
+
+func (s *summaryJobWriter) Write(j *system.Job) error {
+	groups := map[string]string{
+		SummaryByAccount:   j.Account,
+		SummaryByPI:        j.PIUsername,
+		SummaryByPartition: j.Partition,
+		SummaryByCategory:  string(j.Category),
+	}
+	if j.GPUs > 0 {
+		groups[SummaryByGPUType] = cmp.Or(j.GPUType, "untyped")
+	}
+	for group, key := range groups {
+		k := summaryKey{date: j.Date, group: group, key: key}
+		r, ok := s.rows[k]
+		if !ok {
+			r = &summaryRow{states: make(map[types.JobState]int)}
+			s.rows[k] = r
+		}
+		r.jobs++
+		r.states[j.State]++
+		r.cpuHours += j.CPUHoursTotal
+		r.gpuHours += j.GPUHoursTotal
+		r.serviceUnits += j.ServiceUnits
+		r.waitTimeHours += j.WaitTimeHours
+	}
+	return nil
+}
+
+func (s *summaryJobWriter) Close() error {
+	if s.header {
+		if err := s.writer.Write(SummaryKeys()); err != nil {
+			return fmt.Errorf("failed to write header: %v", err)
+		}
+	}
+	keys := make([]summaryKey, 0, len(s.rows))
+	for k := range s.rows {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b summaryKey) int {
+		return cmp.Or(
+			cmp.Compare(a.date, b.date),
+			cmp.Compare(a.group, b.group),
+			cmp.Compare(a.key, b.key),
+		)
+	})
+	for _, k := range keys {
+		r := s.rows[k]
+		fields := []string{
+			k.date,
+			k.group,
+			k.key,
+			fmt.Sprintf("%d", r.jobs),
+		}
+		for _, st := range types.JobStates() {
+			fields = append(fields, fmt.Sprintf("%d", r.states[st]))
+		}
+		fields = append(fields,
+			fmt.Sprintf("%f", r.cpuHours),
+			fmt.Sprintf("%f", r.gpuHours),
+			fmt.Sprintf("%f", r.serviceUnits),
+			fmt.Sprintf("%f", r.waitTimeHours/float64(r.jobs)),
+		)
+		if err := s.writer.Write(fields); err != nil {
+			return err
+		}
+	}
+	s.writer.Flush()
+	return s.writer.Error()
+}
@@ -0,0 +1,148 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+// ErrNotJobsCSV is returned for csv files without the job columns, like the
+// summary, steps or rejects outputs.
+var ErrNotJobsCSV = errors.New("not a jobs csv")
+
+// ReadJobsCSV reads jobs back from csv files written with a header row.
+// Columns missing from older files are left zero.
+func ReadJobsCSV(paths []string) ([]*system.Job, error) {
+	jobs := []*system.Job{}
+	for _, p := range paths {
+		slog.Debug(fmt.Sprintf("  Reading jobs from %s", p))
+		j, err := readJobsCSVFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		jobs = append(jobs, j...)
+	}
+	return jobs, nil
+}
+
+func readJobsCSVFile(path string) ([]*system.Job, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	for i, h := range header {
+		index[h] = i
+	}
+	for _, k := range []string{"JobID", "Account", "PIUsername", "Date"} {
+		if _, ok := index[k]; !ok {
+			return nil, fmt.Errorf("%w: missing column %s, was the file written with -noheader?", ErrNotJobsCSV, k)
+		}
+	}
+
+	jobs := []*system.Job{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		j, err := parseJobRecord(index, record)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+func parseJobRecord(index map[string]int, record []string) (*system.Job, error) {
+	str := func(key string) string {
+		i, ok := index[key]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+	var err error
+	num := func(key string) float64 {
+		v := str(key)
+		if v == "" || err != nil {
+			return 0
+		}
+		f, perr := strconv.ParseFloat(v, 64)
+		if perr != nil {
+			err = fmt.Errorf("failed to parse %s: %v", key, perr)
+		}
+		return f
+	}
+	j := &system.Job{
+		JobID:                str("JobID"),
+		JobName:              str("JobName"),
+		Username:             str("Username"),
+		Account:              str("Account"),
+		Partition:            str("Partition"),
+		Elapsed:              str("Elapsed"),
+		NodeCount:            int(num("NodeCount")),
+		CPUs:                 int(num("CPUs")),
+		TRES:                 str("TRES"),
+		SubmitTime:           str("SubmitTime"),
+		StartTime:            str("StartTime"),
+		EndTime:              str("EndTime"),
+		NodeList:             str("NodeList"),
+		State:                types.JobState(str("State")),
+		QOS:                  str("QOS"),
+		PIUsername:           str("PIUsername"),
+		PIFullName:           str("PIFullName"),
+		AccountStorageGB:     int(num("AccountStorageGB")),
+		Category:             types.JobCategory(str("Category")),
+		OpenuseWeight:        num("OpenuseWeight"),
+		CondoWeight:          num("CondoWeight"),
+		GPUs:                 int(num("GPUs")),
+		CPUHoursOpenUse:      num("CPUHoursOpenUse"),
+		CPUHoursCondo:        num("CPUHoursCondo"),
+		CPUHoursTotal:        num("CPUHoursTotal"),
+		GPUHoursOpenUse:      num("GPUHoursOpenUse"),
+		GPUHoursCondo:        num("GPUHoursCondo"),
+		GPUHoursTotal:        num("GPUHoursTotal"),
+		WaitTimeHours:        num("WaitTimeHours"),
+		RunTimeHours:         num("RunTimeHours"),
+		Date:                 str("Date"),
+		UserFullName:         str("UserFullName"),
+		ServiceUnits:         num("ServiceUnits"),
+		ProratedRunTimeHours: num("ProratedRunTimeHours"),
+		FullCPUHoursTotal:    num("FullCPUHoursTotal"),
+		FullGPUHoursTotal:    num("FullGPUHoursTotal"),
+		StepCount:            int(num("StepCount")),
+		StepCPUHours:         num("StepCPUHours"),
+		StepGPUHours:         num("StepGPUHours"),
+		MemGB:                num("MemGB"),
+		BillingTRES:          int(num("BillingTRES")),
+		GPUType:              str("GPUType"),
+		MemGBHours:           num("MemGBHours"),
+		EffectiveCPUs:        int(num("EffectiveCPUs")),
+	}
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %v", j.JobID, err)
+	}
+	return j, nil
+}
@@ -0,0 +1,182 @@
+package report
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"text/template"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatPDF      = "pdf"
+)
+
+func Formats() []string {
+	return []string{FormatMarkdown, FormatHTML, FormatPDF}
+}
+
+var templateFuncs = map[string]any{
+	"f2": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+}
+
+var markdownTemplate = template.Must(template.New("statement").Funcs(templateFuncs).Parse(
+	`# Usage Statement: {{.Period}}
+
+**PI:** {{.PIFullName}} ({{.PIUsername}})
+
+## Accounts
+
+| Account | Storage (GB) | Jobs | CPU Hours | GPU Hours | Service Units |
+|---|---:|---:|---:|---:|---:|
+{{range .Accounts}}| {{.Account}} | {{.StorageGB}} | {{.Jobs}} | {{f2 .CPUHours}} | {{f2 .GPUHours}} | {{f2 .ServiceUnits}} |
+{{end}}
+## Usage by Partition and Category
+
+| Account | Partition | Category | Jobs | CPU Hours | GPU Hours | Service Units |
+|---|---|---|---:|---:|---:|---:|
+{{range .Lines}}| {{.Account}} | {{.Partition}} | {{.Category}} | {{.Jobs}} | {{f2 .CPUHours}} | {{f2 .GPUHours}} | {{f2 .ServiceUnits}} |
+{{end}}
+## Totals
+
+| Jobs | CPU Hours | GPU Hours | Service Units | Storage (GB) |
+|---:|---:|---:|---:|---:|
+| {{.Total.Jobs}} | {{f2 .Total.CPUHours}} | {{f2 .Total.GPUHours}} | {{f2 .Total.ServiceUnits}} | {{.StorageGB}} |
+`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("statement").Funcs(templateFuncs).Parse(
+	`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Usage Statement: {{.Period}} - {{.PIUsername}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #999; padding: 4px 8px; }
+td.n { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Usage Statement: {{.Period}}</h1>
+<p><strong>PI:</strong> {{.PIFullName}} ({{.PIUsername}})</p>
+<h2>Accounts</h2>
+<table>
+<tr><th>Account</th><th>Storage (GB)</th><th>Jobs</th><th>CPU Hours</th><th>GPU Hours</th><th>Service Units</th></tr>
+{{range .Accounts}}<tr><td>{{.Account}}</td><td class="n">{{.StorageGB}}</td><td class="n">{{.Jobs}}</td><td class="n">{{f2 .CPUHours}}</td><td class="n">{{f2 .GPUHours}}</td><td class="n">{{f2 .ServiceUnits}}</td></tr>
+{{end}}</table>
+<h2>Usage by Partition and Category</h2>
+<table>
+<tr><th>Account</th><th>Partition</th><th>Category</th><th>Jobs</th><th>CPU Hours</th><th>GPU Hours</th><th>Service Units</th></tr>
+{{range .Lines}}<tr><td>{{.Account}}</td><td>{{.Partition}}</td><td>{{.Category}}</td><td class="n">{{.Jobs}}</td><td class="n">{{f2 .CPUHours}}</td><td class="n">{{f2 .GPUHours}}</td><td class="n">{{f2 .ServiceUnits}}</td></tr>
+{{end}}</table>
+<h2>Totals</h2>
+<table>
+<tr><th>Jobs</th><th>CPU Hours</th><th>GPU Hours</th><th>Service Units</th><th>Storage (GB)</th></tr>
+<tr><td class="n">{{.Total.Jobs}}</td><td class="n">{{f2 .Total.CPUHours}}</td><td class="n">{{f2 .Total.GPUHours}}</td><td class="n">{{f2 .Total.ServiceUnits}}</td><td class="n">{{.StorageGB}}</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// Render writes the statement to w in the given format.
+func (st *Statement) Render(w io.Writer, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return markdownTemplate.Execute(w, st)
+	case FormatHTML:
+		return htmlTemplate.Execute(w, st)
+	case FormatPDF:
+		return st.renderPDF(w)
+	}
+	return fmt.Errorf("unknown statement format: %s", format)
+}
+
+func (st *Statement) renderPDF(w io.Writer) error {
+	pdf := fpdf.New("L", "mm", "Letter", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.Cell(0, 10, tr(fmt.Sprintf("Usage Statement: %s", st.Period)))
+	pdf.Ln(10)
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.Cell(0, 8, tr(fmt.Sprintf("PI: %s (%s)", st.PIFullName, st.PIUsername)))
+	pdf.Ln(12)
+
+	// the first textCols columns are left aligned, the numbers after them right
+	table := func(title string, textCols int, widths []float64, header []string, rows [][]string) {
+		pdf.SetFont("Helvetica", "B", 13)
+		pdf.Cell(0, 8, title)
+		pdf.Ln(9)
+		pdf.SetFont("Helvetica", "B", 10)
+		for i, h := range header {
+			pdf.CellFormat(widths[i], 7, h, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 10)
+		for _, r := range rows {
+			for i, v := range r {
+				align := "R"
+				if i < textCols {
+					align = "L"
+				}
+				pdf.CellFormat(widths[i], 6, tr(v), "1", 0, align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		pdf.Ln(6)
+	}
+
+	accountRows := [][]string{}
+	for _, a := range st.Accounts {
+		accountRows = append(accountRows, []string{
+			a.Account,
+			fmt.Sprintf("%d", a.StorageGB),
+			fmt.Sprintf("%d", a.Jobs),
+			fmt.Sprintf("%.2f", a.CPUHours),
+			fmt.Sprintf("%.2f", a.GPUHours),
+			fmt.Sprintf("%.2f", a.ServiceUnits),
+		})
+	}
+	table("Accounts", 1,
+		[]float64{60, 35, 25, 40, 40, 40},
+		[]string{"Account", "Storage (GB)", "Jobs", "CPU Hours", "GPU Hours", "Service Units"},
+		accountRows,
+	)
+
+	lineRows := [][]string{}
+	for _, l := range st.Lines {
+		lineRows = append(lineRows, []string{
+			l.Account,
+			l.Partition,
+			string(l.Category),
+			fmt.Sprintf("%d", l.Jobs),
+			fmt.Sprintf("%.2f", l.CPUHours),
+			fmt.Sprintf("%.2f", l.GPUHours),
+			fmt.Sprintf("%.2f", l.ServiceUnits),
+		})
+	}
+	table("Usage by Partition and Category", 3,
+		[]float64{50, 45, 30, 25, 35, 35, 35},
+		[]string{"Account", "Partition", "Category", "Jobs", "CPU Hours", "GPU Hours", "Service Units"},
+		lineRows,
+	)
+
+	table("Totals", 0,
+		[]float64{25, 40, 40, 40, 35},
+		[]string{"Jobs", "CPU Hours", "GPU Hours", "Service Units", "Storage (GB)"},
+		[][]string{{
+			fmt.Sprintf("%d", st.Total.Jobs),
+			fmt.Sprintf("%.2f", st.Total.CPUHours),
+			fmt.Sprintf("%.2f", st.Total.GPUHours),
+			fmt.Sprintf("%.2f", st.Total.ServiceUnits),
+			fmt.Sprintf("%d", st.StorageGB),
+		}},
+	)
+
+	return pdf.Output(w)
+}
@@ -0,0 +1,125 @@
+package report
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+// Usage totals for a group of jobs.
+type Usage struct {
+	Jobs         int
+	CPUHours     float64
+	GPUHours     float64
+	ServiceUnits float64
+}
+
+func (u *Usage) add(j *system.Job) {
+	u.Jobs++
+	u.CPUHours += j.CPUHoursTotal
+	u.GPUHours += j.GPUHoursTotal
+	u.ServiceUnits += j.ServiceUnits
+}
+
+type StatementAccount struct {
+	Account   string
+	StorageGB int
+	Usage
+}
+
+type StatementLine struct {
+	Account   string
+	Partition string
+	Category  types.JobCategory
+	Usage
+}
+
+// Statement is a PI's usage for one billing period.
+type Statement struct {
+	Period     string
+	PIUsername string
+	PIFullName string
+	Accounts   []*StatementAccount
+	Lines      []*StatementLine
+	Total      Usage
+	StorageGB  int
+}
+
+// NewStatements groups the jobs whose Date falls in period (YYYY-mm) into a
+// statement per PI, sorted by PI username.
+func NewStatements(period string, jobs []*system.Job) []*Statement {
+	statements := make(map[string]*Statement)
+	accounts := make(map[[2]string]*StatementAccount)
+	storageDates := make(map[[2]string]string)
+	lines := make(map[[4]string]*StatementLine)
+
+	for _, j := range jobs {
+		if !strings.HasPrefix(j.Date, period) {
+			continue
+		}
+		st, ok := statements[j.PIUsername]
+		if !ok {
+			st = &Statement{
+				Period:     period,
+				PIUsername: j.PIUsername,
+			}
+			statements[j.PIUsername] = st
+		}
+		if j.PIFullName != "" {
+			st.PIFullName = j.PIFullName
+		}
+		st.Total.add(j)
+
+		ak := [2]string{j.PIUsername, j.Account}
+		a, ok := accounts[ak]
+		if !ok {
+			a = &StatementAccount{Account: j.Account}
+			accounts[ak] = a
+			st.Accounts = append(st.Accounts, a)
+		}
+		a.add(j)
+		// storage is a point in time value, report the latest one seen
+		if j.Date >= storageDates[ak] {
+			storageDates[ak] = j.Date
+			a.StorageGB = j.AccountStorageGB
+		}
+
+		lk := [4]string{j.PIUsername, j.Account, j.Partition, string(j.Category)}
+		l, ok := lines[lk]
+		if !ok {
+			l = &StatementLine{
+				Account:   j.Account,
+				Partition: j.Partition,
+				Category:  j.Category,
+			}
+			lines[lk] = l
+			st.Lines = append(st.Lines, l)
+		}
+		l.add(j)
+	}
+
+	out := []*Statement{}
+	for _, st := range statements {
+		slices.SortFunc(st.Accounts, func(a, b *StatementAccount) int {
+			return cmp.Compare(a.Account, b.Account)
+		})
+		slices.SortFunc(st.Lines, func(a, b *StatementLine) int {
+			return cmp.Or(
+				cmp.Compare(a.Account, b.Account),
+				cmp.Compare(a.Partition, b.Partition),
+				cmp.Compare(a.Category, b.Category),
+			)
+		})
+		for _, a := range st.Accounts {
+			st.StorageGB += a.StorageGB
+		}
+		out = append(out, st)
+	}
+	slices.SortFunc(out, func(a, b *Statement) int {
+		return cmp.Compare(a.PIUsername, b.PIUsername)
+	})
+	return out
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/lcrownover/process-job-stats-go/internal/report"
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+// Index holds the jobs from every processed csv file under a directory,
+// sorted by date and JobID.
+type Index struct {
+	dir   string
+	mutex sync.RWMutex
+	jobs  []*system.Job
+}
+
+func NewIndex(dir string) (*Index, error) {
+	idx := &Index{
+		dir: dir,
+	}
+	if err := idx.Reload(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Reload rescans the directory and swaps in the new set of jobs.
+func (idx *Index) Reload() error {
+	slog.Debug(fmt.Sprintf("  Starting: Indexing %s", idx.dir))
+	paths := []string{}
+	err := filepath.WalkDir(idx.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".csv") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan data dir: %v", err)
+	}
+	// the data dir may also hold the summary, steps or rejects outputs
+	jobs := []*system.Job{}
+	for _, p := range paths {
+		j, err := report.ReadJobsCSV([]string{p})
+		if errors.Is(err, report.ErrNotJobsCSV) {
+			slog.Warn(fmt.Sprintf("Skipping %s: %v", p, err))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, j...)
+	}
+	slices.SortFunc(jobs, func(a, b *system.Job) int {
+		return cmp.Or(
+			cmp.Compare(a.Date, b.Date),
+			cmp.Compare(a.JobID, b.JobID),
+		)
+	})
+
+	idx.mutex.Lock()
+	idx.jobs = jobs
+	idx.mutex.Unlock()
+	slog.Info(fmt.Sprintf("Indexed %d jobs from %d files", len(jobs), len(paths)))
+	return nil
+}
+
+// Filter selects jobs, empty fields match everything. Start and End are
+// inclusive days in YYYY-mm-dd.
+type Filter struct {
+	Account    string
+	Username   string
+	PIUsername string
+	Partition  string
+	Start      string
+	End        string
+}
+
+func (f Filter) matches(j *system.Job) bool {
+	return (f.Account == "" || j.Account == f.Account) &&
+		(f.Username == "" || j.Username == f.Username) &&
+		(f.PIUsername == "" || j.PIUsername == f.PIUsername) &&
+		(f.Partition == "" || j.Partition == f.Partition) &&
+		(f.Start == "" || j.Date >= f.Start) &&
+		(f.End == "" || j.Date <= f.End)
+}
+
+// Jobs returns the matching jobs.
+func (idx *Index) Jobs(f Filter) []*system.Job {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	out := []*system.Job{}
+	for _, j := range idx.jobs {
+		if f.matches(j) {
+			out = append(out, j)
+		}
+	}
+	return out
+}
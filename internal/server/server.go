@@ -0,0 +1,173 @@
+package server
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lcrownover/process-job-stats-go/internal/report"
+	"github.com/lcrownover/process-job-stats-go/internal/system"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+type jobsResponse struct {
+	Total    int           `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Jobs     []*system.Job `json:"jobs"`
+}
+
+type aggregate struct {
+	Key string `json:"Key"`
+	report.Usage
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler serves the JSON API over the index.
+//
+//	GET /api/jobs?account=&user=&pi=&partition=&start=&end=&page=&page_size=
+//	GET /api/aggregates?by=pi|partition|account|category&<same filters>
+func Handler(idx *Index) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/jobs", func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseFilter(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{err.Error()})
+			return
+		}
+		page, pageSize, err := parsePage(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{err.Error()})
+			return
+		}
+		jobs := idx.Jobs(f)
+		start, end := pageBounds(page, pageSize, len(jobs))
+		writeJSON(w, http.StatusOK, jobsResponse{
+			Total:    len(jobs),
+			Page:     page,
+			PageSize: pageSize,
+			Jobs:     jobs[start:end],
+		})
+	})
+	mux.HandleFunc("GET /api/aggregates", func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseFilter(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{err.Error()})
+			return
+		}
+		var key func(*system.Job) string
+		switch r.URL.Query().Get("by") {
+		case "pi":
+			key = func(j *system.Job) string { return j.PIUsername }
+		case "partition":
+			key = func(j *system.Job) string { return j.Partition }
+		case "account":
+			key = func(j *system.Job) string { return j.Account }
+		case "category":
+			key = func(j *system.Job) string { return string(j.Category) }
+		default:
+			writeJSON(w, http.StatusBadRequest, errorResponse{"by must be one of pi, partition, account, category"})
+			return
+		}
+		writeJSON(w, http.StatusOK, aggregateJobs(idx.Jobs(f), key))
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+func aggregateJobs(jobs []*system.Job, key func(*system.Job) string) []*aggregate {
+	groups := make(map[string]*aggregate)
+	for _, j := range jobs {
+		k := key(j)
+		a, ok := groups[k]
+		if !ok {
+			a = &aggregate{Key: k}
+			groups[k] = a
+		}
+		a.Jobs++
+		a.CPUHours += j.CPUHoursTotal
+		a.GPUHours += j.GPUHoursTotal
+		a.ServiceUnits += j.ServiceUnits
+	}
+	out := make([]*aggregate, 0, len(groups))
+	for _, a := range groups {
+		out = append(out, a)
+	}
+	slices.SortFunc(out, func(a, b *aggregate) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return out
+}
+
+func parseFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	f := Filter{
+		Account:    q.Get("account"),
+		Username:   q.Get("user"),
+		PIUsername: q.Get("pi"),
+		Partition:  q.Get("partition"),
+		Start:      q.Get("start"),
+		End:        q.Get("end"),
+	}
+	for _, d := range []string{f.Start, f.End} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return f, fmt.Errorf("invalid date, expected YYYY-mm-dd: %s", d)
+		}
+	}
+	return f, nil
+}
+
+func parsePage(r *http.Request) (int, int, error) {
+	q := r.URL.Query()
+	page := 1
+	pageSize := defaultPageSize
+	var err error
+	if v := q.Get("page"); v != "" {
+		page, err = strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return 0, 0, fmt.Errorf("page must be a positive integer")
+		}
+	}
+	if v := q.Get("page_size"); v != "" {
+		pageSize, err = strconv.Atoi(v)
+		if err != nil || pageSize < 1 || pageSize > maxPageSize {
+			return 0, 0, fmt.Errorf("page_size must be between 1 and %d", maxPageSize)
+		}
+	}
+	return page, pageSize, nil
+}
+
+// pageBounds returns the slice bounds of a page of n results. Pages past the
+// end are empty, checked before multiplying so a huge page can't overflow.
+func pageBounds(page, pageSize, n int) (int, int) {
+	if page-1 > n/pageSize {
+		return n, n
+	}
+	start := min((page-1)*pageSize, n)
+	return start, min(start+pageSize, n)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error(fmt.Sprintf("Failed to write response: %v", err))
+	}
+}
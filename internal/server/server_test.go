@@ -0,0 +1,30 @@
+package server
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		name               string
+		page, pageSize, n  int
+		wantStart, wantEnd int
+	}{
+		{"first page", 1, 10, 25, 0, 10},
+		{"last partial page", 3, 10, 25, 20, 25},
+		{"page past the end", 4, 10, 25, 25, 25},
+		{"exact last page", 2, 10, 20, 10, 20},
+		{"no results", 1, 10, 0, 0, 0},
+		{"huge page", 92233720368547758, 1000, 25, 25, 25},
+		{"max page", math.MaxInt, 1000, 25, 25, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := pageBounds(tt.page, tt.pageSize, tt.n)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("pageBounds(%d, %d, %d) = %d, %d, want %d, %d", tt.page, tt.pageSize, tt.n, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Snapshot is the node to partition map and open-use partition list as they
+// were on a day, so that reprocessing a past day bills nodes by the
+// partitions they were in then rather than now.
+type Snapshot struct {
+	Date              string              `json:"date"`
+	NodePartitions    map[string][]string `json:"node_partitions"`
+	OpenUsePartitions []string            `json:"open_use_partitions"`
+}
+
+// Store keeps one snapshot file per day in a directory, named after the day,
+// e.g. 2025-02-03.json.
+type Store struct {
+	dir    string
+	loaded map[string]*Snapshot
+}
+
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
+	return &Store{
+		dir:    dir,
+		loaded: make(map[string]*Snapshot),
+	}, nil
+}
+
+// Save writes the snapshot for its day, replacing any earlier one.
+func (s *Store) Save(snap *Snapshot) error {
+	slog.Debug(fmt.Sprintf("  Saving partition snapshot for %s", snap.Date))
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, snap.Date+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	s.loaded[snap.Date] = snap
+	return nil
+}
+
+// Effective returns the snapshot in effect on day, which is the latest one
+// taken on or before it, or nil if every snapshot is newer than day.
+func (s *Store) Effective(day string) (*Snapshot, error) {
+	days, err := s.days()
+	if err != nil {
+		return nil, err
+	}
+	effective := ""
+	for _, d := range days {
+		if d <= day {
+			effective = d
+		}
+	}
+	if effective == "" {
+		return nil, nil
+	}
+	if snap, ok := s.loaded[effective]; ok {
+		return snap, nil
+	}
+
+	slog.Debug(fmt.Sprintf("  Loading partition snapshot for %s", effective))
+	b, err := os.ReadFile(filepath.Join(s.dir, effective+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %v", effective, err)
+	}
+	if snap.NodePartitions == nil {
+		return nil, fmt.Errorf("snapshot %s has no node partitions", effective)
+	}
+	s.loaded[effective] = &snap
+	return &snap, nil
+}
+
+// days lists the days there are snapshots for, in order.
+func (s *Store) days() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot directory: %v", err)
+	}
+	days := []string{}
+	for _, e := range entries {
+		d, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			continue
+		}
+		days = append(days, d)
+	}
+	slices.Sort(days)
+	return days, nil
+}
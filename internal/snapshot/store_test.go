@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestStoreEffective(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore error: %v", err)
+	}
+	for _, day := range []string{"2025-03-01", "2025-01-01", "2025-02-01"} {
+		snap := &Snapshot{
+			Date:              day,
+			NodePartitions:    map[string][]string{"n01": {day}},
+			OpenUsePartitions: []string{"compute"},
+		}
+		if err := s.Save(snap); err != nil {
+			t.Fatalf("Save(%s) error: %v", day, err)
+		}
+	}
+	// files that aren't a day's snapshot are ignored
+	for _, name := range []string{"notes.json", "2025-01-15.json.tmp", "2025-13-01.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		day  string
+		want string
+	}{
+		{"2024-12-31", ""},
+		{"2025-01-01", "2025-01-01"},
+		{"2025-01-31", "2025-01-01"},
+		{"2025-02-01", "2025-02-01"},
+		{"2025-02-28", "2025-02-01"},
+		{"2025-06-01", "2025-03-01"},
+	}
+	// a fresh store reads the snapshots back from disk
+	for _, store := range []*Store{s, mustNewStore(t, dir)} {
+		for _, tt := range tests {
+			snap, err := store.Effective(tt.day)
+			if err != nil {
+				t.Fatalf("Effective(%s) error: %v", tt.day, err)
+			}
+			if tt.want == "" {
+				if snap != nil {
+					t.Errorf("Effective(%s) = %s, want nil", tt.day, snap.Date)
+				}
+				continue
+			}
+			if snap == nil {
+				t.Errorf("Effective(%s) = nil, want %s", tt.day, tt.want)
+				continue
+			}
+			if snap.Date != tt.want || !slices.Equal(snap.NodePartitions["n01"], []string{tt.want}) {
+				t.Errorf("Effective(%s) = %s %v, want %s", tt.day, snap.Date, snap.NodePartitions, tt.want)
+			}
+		}
+	}
+}
+
+func TestStoreEffectiveEmpty(t *testing.T) {
+	s := mustNewStore(t, filepath.Join(t.TempDir(), "snapshots"))
+	snap, err := s.Effective("2025-01-01")
+	if err != nil {
+		t.Fatalf("Effective error: %v", err)
+	}
+	if snap != nil {
+		t.Errorf("Effective = %s, want nil", snap.Date)
+	}
+}
+
+func mustNewStore(t *testing.T, dir string) *Store {
+	t.Helper()
+	s, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore error: %v", err)
+	}
+	return s
+}
@@ -10,6 +10,8 @@ import (
 	"os/user"
 	"strconv"
 	"strings"
+
+	"github.com/lcrownover/process-job-stats-go/internal/types"
 )
 
 type AccountPIs struct {
@@ -18,10 +20,14 @@ type AccountPIs struct {
 
 func NewAccountPIs(ctx context.Context) (*AccountPIs, error) {
 	slog.Debug("  Starting: Getting Account -> PI associations")
+	projectsDir := ctx.Value(types.ProjectsDirKey)
+	if projectsDir == nil {
+		return nil, fmt.Errorf("failed to find projects dir in context")
+	}
 	cmd := exec.Command(
 		"bash",
 		"-c",
-		"ls -l /gpfs/projects/ | awk '{print $3\", \"$9}'",
+		fmt.Sprintf("ls -l %s/ | awk '{print $3\", \"$9}'", projectsDir),
 	)
 	var outb, errb bytes.Buffer
 	cmd.Stdout = &outb
@@ -22,11 +22,15 @@ func NewAccountStorages(ctx context.Context) (*AccountStorages, error) {
 	if gpfsBinDir == nil {
 		return nil, fmt.Errorf("failed to find gpfs bin dir in context")
 	}
+	gpfsFilesystem := ctx.Value(types.GpfsFilesystemKey)
+	if gpfsFilesystem == nil {
+		return nil, fmt.Errorf("failed to find gpfs filesystem in context")
+	}
 	mmrepquotaBin := fmt.Sprintf("%s/mmrepquota", gpfsBinDir)
 	cmd := exec.Command(
 		"bash",
 		"-c",
-		fmt.Sprintf("%s -j fs1 --block-size g | awk '/FILESET/ {print $1\",\"$4}'", mmrepquotaBin),
+		fmt.Sprintf("%s -j %s --block-size g | awk '/FILESET/ {print $1\",\"$4}'", mmrepquotaBin, gpfsFilesystem),
 	)
 	var outb, errb bytes.Buffer
 	cmd.Stdout = &outb
@@ -60,11 +64,17 @@ func NewAccountStorages(ctx context.Context) (*AccountStorages, error) {
 	}, nil
 }
 
-func (as *AccountStorages) GetStorage(account string) (int, error) {
+// GetStorage returns the storage quota of an account in GB. Accounts without
+// a fileset have no storage, so ok is false and the quota 0, and err is only
+// set when the quota can't be parsed.
+func (as *AccountStorages) GetStorage(account string) (int, bool, error) {
 	p, ok := as.data[account]
 	if !ok {
-		return 0, fmt.Errorf("failed to find account")
+		return 0, false, nil
 	}
 	v, err := strconv.Atoi(p)
-	return v, err
+	if err != nil {
+		return 0, true, fmt.Errorf("failed to parse storage quota %s: %v", p, err)
+	}
+	return v, true, nil
 }
@@ -0,0 +1,18 @@
+package system
+
+import "testing"
+
+func TestGetStorage(t *testing.T) {
+	as := &AccountStorages{
+		data: map[string]string{"hpc": "120", "broken": "12T"},
+	}
+	if gb, ok, err := as.GetStorage("hpc"); gb != 120 || !ok || err != nil {
+		t.Errorf("GetStorage(hpc) = %d, %v, %v, want 120, true, nil", gb, ok, err)
+	}
+	if gb, ok, err := as.GetStorage("nofileset"); gb != 0 || ok || err != nil {
+		t.Errorf("GetStorage(nofileset) = %d, %v, %v, want 0, false, nil", gb, ok, err)
+	}
+	if _, _, err := as.GetStorage("broken"); err == nil {
+		t.Error("GetStorage(broken) succeeded, want error")
+	}
+}
@@ -0,0 +1,69 @@
+package system
+
+import (
+	"fmt"
+	"time"
+)
+
+const dayLayout = "2006-01-02"
+
+// NewProcessDays resolves the day, start/end and month flags into the ordered
+// list of days to process. With nothing set it defaults to yesterday.
+//
+//	day:        single day in YYYY-mm-dd
+//	start/end:  inclusive range in YYYY-mm-dd, end defaults to start
+//	month:      every day in YYYY-mm
+func NewProcessDays(day, start, end, month string) ([]string, error) {
+	set := 0
+	for _, s := range []string{day, start, month} {
+		if s != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("only one of day, start/end or month can be provided")
+	}
+	if end != "" && start == "" {
+		return nil, fmt.Errorf("end provided without start")
+	}
+
+	switch {
+	case day != "":
+		d, err := time.Parse(dayLayout, day)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse day: %s", day)
+		}
+		return []string{d.Format(dayLayout)}, nil
+	case start != "":
+		s, err := time.Parse(dayLayout, start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse start: %s", start)
+		}
+		e := s
+		if end != "" {
+			e, err = time.Parse(dayLayout, end)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse end: %s", end)
+			}
+		}
+		if e.Before(s) {
+			return nil, fmt.Errorf("end %s is before start %s", end, start)
+		}
+		return daysBetween(s, e), nil
+	case month != "":
+		m, err := time.Parse("2006-01", month)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse month: %s", month)
+		}
+		return daysBetween(m, m.AddDate(0, 1, -1)), nil
+	}
+	return []string{time.Now().Add(-24 * time.Hour).Format(dayLayout)}, nil
+}
+
+func daysBetween(start, end time.Time) []string {
+	days := []string{}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format(dayLayout))
+	}
+	return days
+}
@@ -0,0 +1,232 @@
+package system
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxHostListHosts caps how many hosts an expression may expand to, so a
+// malformed record like n[1-999999999] fails instead of exhausting memory.
+// It is well above the node count of any job.
+const maxHostListHosts = 100000
+
+// ExpandHostList expands a Slurm hostlist expression into individual hosts,
+// matching `scontrol show hostnames`.
+//
+//	n[01-03,07]        -> n01,n02,n03,n07
+//	n[01-02]-ib[1-2]   -> n01-ib1,n01-ib2,n02-ib1,n02-ib2
+//	n0335,gpu[1-2]     -> n0335,gpu1,gpu2
+func ExpandHostList(hostList string) ([]string, error) {
+	exprs, err := splitHostList(hostList)
+	if err != nil {
+		return nil, err
+	}
+	hosts := []string{}
+	for _, e := range exprs {
+		h, err := expandHostExpr(e)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, h...)
+		if len(hosts) > maxHostListHosts {
+			return nil, fmt.Errorf("hostlist expands to more than %d hosts: %s", maxHostListHosts, hostList)
+		}
+	}
+	return hosts, nil
+}
+
+// splitHostList splits on the commas that are outside brackets.
+func splitHostList(hostList string) ([]string, error) {
+	exprs := []string{}
+	depth := 0
+	start := 0
+	for i, c := range hostList {
+		switch c {
+		case '[':
+			depth++
+			if depth > 1 {
+				return nil, fmt.Errorf("nested brackets in hostlist: %s", hostList)
+			}
+		case ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced brackets in hostlist: %s", hostList)
+			}
+		case ',':
+			if depth == 0 {
+				exprs = append(exprs, hostList[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced brackets in hostlist: %s", hostList)
+	}
+	exprs = append(exprs, hostList[start:])
+
+	out := []string{}
+	for _, e := range exprs {
+		e = strings.TrimSpace(e)
+		if e != "" {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// expandHostExpr expands a single expression, which may hold several bracket
+// groups. Groups are expanded left to right, the leftmost varying slowest.
+func expandHostExpr(expr string) ([]string, error) {
+	open := strings.Index(expr, "[")
+	if open < 0 {
+		if strings.Contains(expr, "]") {
+			return nil, fmt.Errorf("unbalanced brackets in hostlist: %s", expr)
+		}
+		return []string{expr}, nil
+	}
+	close := strings.Index(expr, "]")
+	if close < open {
+		return nil, fmt.Errorf("unbalanced brackets in hostlist: %s", expr)
+	}
+	prefix := expr[:open]
+	ids, err := expandRanges(expr[open+1 : close])
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand %s: %v", expr, err)
+	}
+	suffixes, err := expandHostExpr(expr[close+1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(suffixes) == 0 {
+		suffixes = []string{""}
+	}
+	if len(ids)*len(suffixes) > maxHostListHosts {
+		return nil, fmt.Errorf("hostlist expands to more than %d hosts: %s", maxHostListHosts, expr)
+	}
+	hosts := make([]string, 0, len(ids)*len(suffixes))
+	for _, id := range ids {
+		for _, s := range suffixes {
+			hosts = append(hosts, prefix+id+s)
+		}
+	}
+	return hosts, nil
+}
+
+// expandRanges expands the inside of a bracket group, e.g. "01-03,07".
+// Zero padding follows the width of the lower bound.
+func expandRanges(ranges string) ([]string, error) {
+	ids := []string{}
+	for _, r := range strings.Split(ranges, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			return nil, fmt.Errorf("empty range")
+		}
+		lo, hi, isRange := strings.Cut(r, "-")
+		loN, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range start %q", lo)
+		}
+		if !isRange {
+			ids = append(ids, lo)
+			continue
+		}
+		hiN, err := strconv.Atoi(hi)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range end %q", hi)
+		}
+		if hiN < loN {
+			return nil, fmt.Errorf("range end before start: %s", r)
+		}
+		if hiN-loN+1 > maxHostListHosts-len(ids) {
+			return nil, fmt.Errorf("range expands to more than %d hosts: %s", maxHostListHosts, r)
+		}
+		width := len(lo)
+		for i := loN; i <= hiN; i++ {
+			ids = append(ids, fmt.Sprintf("%0*d", width, i))
+		}
+	}
+	return ids, nil
+}
+
+// CompressHostList is the reverse of ExpandHostList, folding hosts that share
+// a prefix and numeric width into bracket ranges. Groups keep the order in
+// which their prefix first appears.
+//
+//	n01,n02,n03,n07,gpu1 -> n[01-03,07],gpu1
+func CompressHostList(hosts []string) string {
+	type group struct {
+		prefix string
+		width  int
+		nums   []int
+	}
+	groups := []*group{}
+	index := map[string]*group{}
+	seen := map[string]bool{}
+
+	for _, h := range hosts {
+		if h == "" || seen[h] {
+			continue
+		}
+		seen[h] = true
+		i := len(h)
+		for i > 0 && h[i-1] >= '0' && h[i-1] <= '9' {
+			i--
+		}
+		if i == len(h) {
+			groups = append(groups, &group{prefix: h, width: -1})
+			continue
+		}
+		prefix, digits := h[:i], h[i:]
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			groups = append(groups, &group{prefix: h, width: -1})
+			continue
+		}
+		// zero padded numbers group by width, unpadded numbers join a padded
+		// group of the same width (n01..n09,n10) or share an unpadded group
+		width := len(digits)
+		if digits[0] != '0' || len(digits) == 1 {
+			if _, ok := index[fmt.Sprintf("%s/%d", prefix, width)]; !ok {
+				width = 0
+			}
+		}
+		key := fmt.Sprintf("%s/%d", prefix, width)
+		g, ok := index[key]
+		if !ok {
+			g = &group{prefix: prefix, width: width}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.nums = append(g.nums, n)
+	}
+
+	parts := []string{}
+	for _, g := range groups {
+		if g.width < 0 {
+			parts = append(parts, g.prefix)
+			continue
+		}
+		if len(g.nums) == 1 {
+			parts = append(parts, fmt.Sprintf("%s%0*d", g.prefix, g.width, g.nums[0]))
+			continue
+		}
+		sort.Ints(g.nums)
+		ranges := []string{}
+		for i := 0; i < len(g.nums); {
+			j := i
+			for j+1 < len(g.nums) && g.nums[j+1] == g.nums[j]+1 {
+				j++
+			}
+			if i == j {
+				ranges = append(ranges, fmt.Sprintf("%0*d", g.width, g.nums[i]))
+			} else {
+				ranges = append(ranges, fmt.Sprintf("%0*d-%0*d", g.width, g.nums[i], g.width, g.nums[j]))
+			}
+			i = j + 1
+		}
+		parts = append(parts, fmt.Sprintf("%s[%s]", g.prefix, strings.Join(ranges, ",")))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,101 @@
+package system
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExpandHostList(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostList string
+		want     []string
+	}{
+		{"single host", "n0335", []string{"n0335"}},
+		{"host list", "n0335,n0336", []string{"n0335", "n0336"}},
+		{"range keeps padding", "n[01-03]", []string{"n01", "n02", "n03"}},
+		{"padding widens past the bound", "n[098-101]", []string{"n098", "n099", "n100", "n101"}},
+		{"unpadded range", "gpu[9-11]", []string{"gpu9", "gpu10", "gpu11"}},
+		{"comma list in brackets", "n[01-02,07,10-11]", []string{"n01", "n02", "n07", "n10", "n11"}},
+		{"multiple bracket groups", "n[01-02]-ib[1-2]", []string{"n01-ib1", "n01-ib2", "n02-ib1", "n02-ib2"}},
+		{"mixed list", "n0335,gpu[1-2],login1", []string{"n0335", "gpu1", "gpu2", "login1"}},
+		{"suffix after brackets", "rack[1-2]n", []string{"rack1n", "rack2n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandHostList(tt.hostList)
+			if err != nil {
+				t.Fatalf("ExpandHostList(%q) error: %v", tt.hostList, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ExpandHostList(%q) = %v, want %v", tt.hostList, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandHostListErrors(t *testing.T) {
+	for _, hostList := range []string{
+		"n[01-03",
+		"n01-03]",
+		"n[[01]]",
+		"n[03-01]",
+		"n[a-b]",
+		"n[01,]",
+		"n[1-999999999]",
+		"n[1-1000]-ib[1-1000]",
+	} {
+		if got, err := ExpandHostList(hostList); err == nil {
+			t.Errorf("ExpandHostList(%q) = %d hosts, want error", hostList, len(got))
+		}
+	}
+}
+
+func TestCompressHostList(t *testing.T) {
+	tests := []struct {
+		name  string
+		hosts []string
+		want  string
+	}{
+		{"single host", []string{"n0335"}, "n0335"},
+		{"range and single", []string{"n01", "n02", "n03", "n07", "gpu1"}, "n[01-03,07],gpu1"},
+		{"unpadded range", []string{"gpu9", "gpu10", "gpu11"}, "gpu[9-11]"},
+		{"padding widens past the bound", []string{"n098", "n099", "n100", "n101"}, "n[098-101]"},
+		{"hosts without numbers", []string{"login", "n01", "n02"}, "login,n[01-02]"},
+		{"unsorted and duplicate hosts", []string{"n03", "n01", "n02", "n01"}, "n[01-03]"},
+		{"different widths stay apart", []string{"n01", "n001"}, "n01,n001"},
+		{"empty", []string{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompressHostList(tt.hosts); got != tt.want {
+				t.Errorf("CompressHostList(%v) = %q, want %q", tt.hosts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressHostListRoundTrip(t *testing.T) {
+	for _, hosts := range [][]string{
+		{"n0335"},
+		{"n01", "n02", "n03", "n07", "n10", "n11"},
+		{"gpu8", "gpu9", "gpu10", "gpu11", "gpu100"},
+		{"n098", "n099", "n100", "n101"},
+		{"n01-ib1", "n01-ib2", "n02-ib1"},
+		{"login", "n0", "n1", "n2"},
+		{"n10", "n01", "n11", "n001", "n1"},
+	} {
+		compressed := CompressHostList(hosts)
+		got, err := ExpandHostList(compressed)
+		if err != nil {
+			t.Errorf("ExpandHostList(%q) error: %v", compressed, err)
+			continue
+		}
+		want := slices.Clone(hosts)
+		slices.Sort(want)
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("ExpandHostList(CompressHostList(%v)) = %v via %q", hosts, got, compressed)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"slices"
 	"strconv"
 	"strings"
@@ -28,6 +29,7 @@ type Job struct {
 	EndTime    string
 	NodeList   string
 	State      types.JobState
+	QOS        string
 
 	// Generated Fields
 	PIUsername       string
@@ -48,9 +50,42 @@ type Job struct {
 	Date             string
 	UserFullName     string
 	ServiceUnits     float64
+
+	// Prorate mode, the hours above only cover the processed day and these
+	// keep the job's full hours
+	ProratedRunTimeHours float64
+	FullCPUHoursTotal    float64
+	FullGPUHoursTotal    float64
+
+	// Step mode rollups, the steps themselves are written separately
+	Steps        []*JobStep `json:"-"`
+	StepCount    int
+	StepCPUHours float64
+	StepGPUHours float64
+
+	// Parsed from TRES
+	AllocTRES   *TRES `json:"-"`
+	MemGB       float64
+	BillingTRES int
+	GPUType     string
+
+	// GPU models from the TRES, or from the nodes' gres when the TRES isn't
+	// typed, and the GPU hours split across them
+	GPUTypes       map[string]int `json:"-"`
+	GPUHoursByType map[string]float64
+
+	// NodeCountedWeights is set when the job ran on both open-use and condo
+	// nodes and scontrol didn't have its CPUs for every node, so the nodes
+	// were counted instead
+	NodeCountedWeights bool `json:"-"`
+
+	// EffectiveCPUs are the CPUs charged, which the effective cores charge
+	// model raises for jobs using more than their share of memory
+	MemGBHours    float64
+	EffectiveCPUs int
 }
 
-// job_id|job_name|username|account|partition|elapsed|nodes|cpus|tres|submit_time|start_time|nodelist
+// job_id|job_name|username|account|partition|elapsed|nodes|cpus|tres|submit_time|start_time|end_time|nodelist|state|qos
 // 29148459_925|ld_stats_array|akapoor|kernlab|kern|00:07:23|1|8|billing=8,cpu=8,mem=64G,node=1|2025-02-03T23:38:14|2025-02-03T23:53:21|n0335
 func NewJob(ctx context.Context, jobString string) (*Job, error) {
 	var err error
@@ -61,12 +96,23 @@ func NewJob(ctx context.Context, jobString string) (*Job, error) {
 	accountStorages := ctx.Value(types.AccountStoragesKey).(*AccountStorages)
 	nlc := ctx.Value(types.NodeListCacheKey).(*nodeListCache)
 	ulc := ctx.Value(types.UserListCacheKey).(*userListCache)
-	if nodePartitions == nil || accountPIs == nil || accountStorages == nil {
+	serviceUnitRates, _ := ctx.Value(types.ServiceUnitRatesKey).([]types.ServiceUnitRule)
+	nodeGres, _ := ctx.Value(types.NodeGresKey).(*NodeGres)
+	chargeModel, _ := ctx.Value(types.ChargeModelKey).(string)
+	nodeMemory, _ := ctx.Value(types.NodeMemoryKey).(*NodeMemory)
+	scontrolJobDetail, _ := ctx.Value(types.ScontrolJobDetailKey).(bool)
+	if chargeModel == types.ChargeModelEffectiveCores && nodeMemory == nil {
+		return nil, fmt.Errorf("failed to unpack node memory from context")
+	}
+	if nodePartitions == nil || accountPIs == nil || accountStorages == nil || serviceUnitRates == nil {
 		return nil, fmt.Errorf("failed to unpack data from context")
 	}
 	slog.Debug("  Starting: Parsing job")
 	slog.Debug(fmt.Sprintf("    %s", jobString))
 	parts := strings.Split(jobString, "|")
+	if len(parts) < 14 {
+		return nil, newJobError(types.JobStageParse, "expected at least 14 fields, got %d", len(parts))
+	}
 	j := &Job{}
 	j.JobID = parts[0]
 	j.JobName = parts[1]
@@ -76,11 +122,11 @@ func NewJob(ctx context.Context, jobString string) (*Job, error) {
 	j.Elapsed = parts[5]
 	j.NodeCount, err = strconv.Atoi(parts[6])
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse nodes: %v", err)
+		return nil, newJobError(types.JobStageParse, "failed to parse nodes: %v", err)
 	}
 	j.CPUs, err = strconv.Atoi(parts[7])
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse nodes: %v", err)
+		return nil, newJobError(types.JobStageParse, "failed to parse nodes: %v", err)
 	}
 	j.TRES = parts[8]
 	j.SubmitTime = parts[9]
@@ -89,7 +135,7 @@ func NewJob(ctx context.Context, jobString string) (*Job, error) {
 
 	j.NodeList, err = expandNodeList(ctx, nlc, parts[12])
 	if err != nil {
-		return nil, fmt.Errorf("failed to expand nodelist: %v", err)
+		return nil, newJobError(types.JobStageNodeList, "failed to expand nodelist: %v", err)
 	}
 	// jobs that were cancelled before running
 	if j.NodeList == "" {
@@ -98,88 +144,156 @@ func NewJob(ctx context.Context, jobString string) (*Job, error) {
 
 	j.State, err = getJobState(parts[13])
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse job state: %v", err)
+		return nil, newJobError(types.JobStageState, "failed to parse job state: %v", err)
+	}
+	// QOS was added to the sacct format later, older saved dumps won't have it
+	if len(parts) > 14 {
+		j.QOS = parts[14]
 	}
 
 	j.PIUsername, ok = accountPIs.GetPI(j.Account)
 	if !ok {
-		return nil, fmt.Errorf("failed to get PI for account: %s", j.Account)
+		return nil, newJobError(types.JobStagePI, "failed to get PI for account: %s", j.Account)
 	}
 
 	j.PIFullName, err = getUserFullName(ulc, j.PIUsername)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get full name for PI username: %v", err)
+		return nil, newJobError(types.JobStageUser, "failed to get full name for PI username: %v", err)
 	}
 
-	j.AccountStorageGB, err = accountStorages.GetStorage(j.Account)
+	j.AccountStorageGB, ok, err = accountStorages.GetStorage(j.Account)
+	if err != nil {
+		return nil, newJobError(types.JobStageStorage, "failed to get account storage for account %s: %v", j.Account, err)
+	}
 	if !ok {
-		return nil, fmt.Errorf("failed to get account storage for account %s: %v", j.Account, err)
+		slog.Debug(fmt.Sprintf("  No fileset for account %s, using 0 GB of storage", j.Account))
 	}
 
 	j.Category, err = categorizeJob(ctx, j.Partition)
 	if err != nil {
-		return nil, fmt.Errorf("failed to categorize job: %v", err)
+		return nil, newJobError(types.JobStageCategory, "failed to categorize job: %v", err)
 	}
 
-	j.OpenuseWeight, err = calculateWeight(ctx, types.JobCategoryOpen, j.NodeList)
+	j.OpenuseWeight, j.CondoWeight, err = calculateWeights(ctx, j.NodeList, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate openuse weight: %v", err)
+		return nil, newJobError(types.JobStageWeight, "%v", err)
+	}
+	// counting nodes is only off when the job ran on both open-use and condo
+	// nodes, scontrol can say how many CPUs it had on each
+	if scontrolJobDetail && j.OpenuseWeight > 0 && j.CondoWeight > 0 {
+		nodeCPUs, err := getJobNodeCPUs(ctx, j.JobID, j.NodeList)
+		if err != nil {
+			slog.Debug(fmt.Sprintf("  Falling back to node counted weights: %v", err))
+			j.NodeCountedWeights = true
+		} else {
+			j.OpenuseWeight, j.CondoWeight, err = calculateWeights(ctx, j.NodeList, nodeCPUs)
+			if err != nil {
+				return nil, newJobError(types.JobStageWeight, "%v", err)
+			}
+		}
 	}
 
-	j.CondoWeight, err = calculateWeight(ctx, types.JobCategoryCondo, j.NodeList)
+	j.AllocTRES, err = ParseTRES(j.TRES)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate condo weight: %v", err)
+		return nil, newJobError(types.JobStageTRES, "failed to parse tres: %v", err)
+	}
+	j.GPUs = j.AllocTRES.GPUs
+	j.MemGB = j.AllocTRES.MemGB
+	j.BillingTRES = j.AllocTRES.Billing
+	j.GPUTypes = j.AllocTRES.GPUTypes
+	if len(j.GPUTypes) == 0 && j.GPUs > 0 && nodeGres != nil {
+		j.GPUTypes = nodeGres.jobGPUTypes(j.NodeList, j.GPUs)
 	}
+	j.GPUType = gpuTypeName(j.GPUTypes)
 
-	j.GPUs, err = calculateGPUsFromTRES(j.TRES)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse gpus from tres: %v", err)
+	j.EffectiveCPUs = j.CPUs
+	if chargeModel == types.ChargeModelEffectiveCores {
+		j.EffectiveCPUs = nodeMemory.effectiveCPUs(j.NodeList, j.CPUs, j.MemGB)
+		slog.Debug(fmt.Sprintf("  Effective cores: %d", j.EffectiveCPUs))
 	}
 
 	j.WaitTimeHours, err = calculateWaitTimeHours(j.SubmitTime, j.StartTime)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate wait time hours: %v", err)
+		return nil, newJobError(types.JobStageTime, "failed to calculate wait time hours: %v", err)
 	}
 
 	j.RunTimeHours, err = calculateRunTimeHours(j.Elapsed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate run time hours: %v", err)
+		return nil, newJobError(types.JobStageTime, "failed to calculate run time hours: %v", err)
 	}
 
 	slog.Debug("  Calculating OpenUse CPU Hours")
-	j.CPUHoursOpenUse, err = calculateComputeHours(j.CPUs, j.OpenuseWeight, j.Elapsed)
+	j.CPUHoursOpenUse, err = calculateComputeHours(j.EffectiveCPUs, j.OpenuseWeight, j.Elapsed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate openuse cpu hours: %v", err)
+		return nil, newJobError(types.JobStageTime, "failed to calculate openuse cpu hours: %v", err)
 	}
 
 	slog.Debug("  Calculating Condo CPU Hours")
-	j.CPUHoursCondo, err = calculateComputeHours(j.CPUs, j.CondoWeight, j.Elapsed)
+	j.CPUHoursCondo, err = calculateComputeHours(j.EffectiveCPUs, j.CondoWeight, j.Elapsed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate condo cpu hours: %v", err)
+		return nil, newJobError(types.JobStageTime, "failed to calculate condo cpu hours: %v", err)
 	}
 	j.CPUHoursTotal = j.CPUHoursOpenUse + j.CPUHoursCondo
 
 	slog.Debug("  Calculating OpenUse GPU Hours")
 	j.GPUHoursOpenUse, err = calculateComputeHours(j.GPUs, j.OpenuseWeight, j.Elapsed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate openuse gpu hours: %v", err)
+		return nil, newJobError(types.JobStageTime, "failed to calculate openuse gpu hours: %v", err)
 	}
 
 	slog.Debug("  Calculating Condo GPU Hours")
 	j.GPUHoursCondo, err = calculateComputeHours(j.GPUs, j.CondoWeight, j.Elapsed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate condo gpu hours: %v", err)
+		return nil, newJobError(types.JobStageTime, "failed to calculate condo gpu hours: %v", err)
 	}
 	j.GPUHoursTotal = j.GPUHoursOpenUse + j.GPUHoursCondo
 
+	j.MemGBHours = j.MemGB * j.RunTimeHours
+
+	j.ProratedRunTimeHours = j.RunTimeHours
+	j.FullCPUHoursTotal = j.CPUHoursTotal
+	j.FullGPUHoursTotal = j.GPUHoursTotal
+	if prorate, _ := ctx.Value(types.ProrateKey).(bool); prorate {
+		fraction, err := calculateProrateFraction(*processDayDate, j.StartTime, j.EndTime, j.Elapsed)
+		if err != nil {
+			return nil, newJobError(types.JobStageProrate, "failed to prorate job: %v", err)
+		}
+		slog.Debug(fmt.Sprintf("  Prorating job to processed day: %f", fraction))
+		j.ProratedRunTimeHours = j.RunTimeHours * fraction
+		j.CPUHoursOpenUse *= fraction
+		j.CPUHoursCondo *= fraction
+		j.CPUHoursTotal *= fraction
+		j.GPUHoursOpenUse *= fraction
+		j.GPUHoursCondo *= fraction
+		j.GPUHoursTotal *= fraction
+		j.MemGBHours *= fraction
+	}
+
+	j.GPUHoursByType = make(map[string]float64)
+	gpuTypeCount := 0
+	for _, n := range j.GPUTypes {
+		gpuTypeCount += n
+	}
+	for model, n := range j.GPUTypes {
+		j.GPUHoursByType[model] = j.GPUHoursTotal * float64(n) / float64(gpuTypeCount)
+	}
+
 	j.Date = *processDayDate
 
 	j.UserFullName, err = getUserFullName(ulc, j.Username)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get full name for username: %v", err)
+		return nil, newJobError(types.JobStageUser, "failed to get full name for username: %v", err)
 	}
 
-	j.ServiceUnits = calculateServiceUnits(j.Category, j.Partition, j.CPUHoursOpenUse, j.CPUHoursCondo, j.GPUHoursOpenUse, j.GPUHoursCondo)
+	j.ServiceUnits, err = calculateServiceUnits(serviceUnitRates, j)
+	if err != nil {
+		return nil, newJobError(types.JobStageServiceUnits, "failed to calculate service units: %v", err)
+	}
+
+	if steps, ok := ctx.Value(types.JobStepsKey).(map[string][]*JobStep); ok {
+		j.Steps = steps[j.JobID]
+		j.StepCount, j.StepCPUHours, j.StepGPUHours = rollupSteps(j.Steps)
+	}
 
 	slog.Debug("  Finished: Parsing job")
 	return j, nil
@@ -219,6 +333,18 @@ func JobKeys() []string {
 		"Date",
 		"UserFullName",
 		"ServiceUnits",
+		"QOS",
+		"ProratedRunTimeHours",
+		"FullCPUHoursTotal",
+		"FullGPUHoursTotal",
+		"StepCount",
+		"StepCPUHours",
+		"StepGPUHours",
+		"MemGB",
+		"BillingTRES",
+		"GPUType",
+		"MemGBHours",
+		"EffectiveCPUs",
 	}
 }
 
@@ -256,9 +382,47 @@ func (j *Job) Fields() []string {
 		j.Date,
 		j.UserFullName,
 		fmt.Sprintf("%f", j.ServiceUnits),
+		j.QOS,
+		fmt.Sprintf("%f", j.ProratedRunTimeHours),
+		fmt.Sprintf("%f", j.FullCPUHoursTotal),
+		fmt.Sprintf("%f", j.FullGPUHoursTotal),
+		fmt.Sprintf("%d", j.StepCount),
+		fmt.Sprintf("%f", j.StepCPUHours),
+		fmt.Sprintf("%f", j.StepGPUHours),
+		fmt.Sprintf("%f", j.MemGB),
+		fmt.Sprintf("%d", j.BillingTRES),
+		j.GPUType,
+		fmt.Sprintf("%f", j.MemGBHours),
+		fmt.Sprintf("%d", j.EffectiveCPUs),
+	}
+}
+
+// JobGPUHoursKeys are the columns of the GPU hours output, which has a row
+// for each job and GPU model.
+func JobGPUHoursKeys() []string {
+	return []string{
+		"JobID",
+		"Date",
+		"GPUType",
+		"GPUHours",
 	}
 }
 
+// GPUHoursFields returns a JobGPUHoursKeys row for each GPU model of the job,
+// sorted by model. Jobs with untyped GPUs have no rows.
+func (j *Job) GPUHoursFields() [][]string {
+	rows := [][]string{}
+	for _, model := range slices.Sorted(maps.Keys(j.GPUHoursByType)) {
+		rows = append(rows, []string{
+			j.JobID,
+			j.Date,
+			model,
+			fmt.Sprintf("%f", j.GPUHoursByType[model]),
+		})
+	}
+	return rows
+}
+
 // elapsed could be in two forms:
 //
 //	1-00:00:00 -> days-hours:minutes:seconds
@@ -299,15 +463,29 @@ func categorizeJob(ctx context.Context, partition string) (types.JobCategory, er
 	if slices.Contains(*openusePartitions, partition) {
 		return types.JobCategoryOpen, nil
 	}
-	if partition == "preempt" {
+	if partition == ctx.Value(types.PreemptPartitionKey) {
 		return types.JobCategoryPreempt, nil
 	}
 	return types.JobCategoryCondo, nil
 }
 
+// calculateWeights returns the open-use and condo weights of a job.
+func calculateWeights(ctx context.Context, nodeList string, nodeCPUs map[string]int) (float64, float64, error) {
+	openuse, err := calculateWeight(ctx, types.JobCategoryOpen, nodeList, nodeCPUs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to calculate openuse weight: %v", err)
+	}
+	condo, err := calculateWeight(ctx, types.JobCategoryCondo, nodeList, nodeCPUs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to calculate condo weight: %v", err)
+	}
+	return openuse, condo, nil
+}
+
 // This represents the weight of the nodes that ran in open-use nodes.
 // Used to multiply by things like CPU Hours, etc, to properly weight jobs.
-func calculateWeight(ctx context.Context, category types.JobCategory, nodeList string) (float64, error) {
+// Each node counts once, or by the CPUs the job had on it when nodeCPUs is set.
+func calculateWeight(ctx context.Context, category types.JobCategory, nodeList string, nodeCPUs map[string]int) (float64, error) {
 	slog.Debug(fmt.Sprintf("    Started Calculating Weight for %s: %s", string(category), nodeList))
 	if !slices.Contains([]types.JobCategory{types.JobCategoryOpen, types.JobCategoryCondo}, category) {
 		return 0.0, fmt.Errorf("category must be JobCategoryOpen or JobCategoryCondo")
@@ -320,59 +498,52 @@ func calculateWeight(ctx context.Context, category types.JobCategory, nodeList s
 	if nodePartitions == nil {
 		return 0.0, fmt.Errorf("failed to unpack node partitions from context")
 	}
+	precedence, _ := ctx.Value(types.NodePrecedenceKey).(string)
 	c := float64(0)
 	nodes := strings.Split(nodeList, ",")
-	nl := len(nodes)
-	slog.Debug(fmt.Sprintf("      nodeList length: %d", nl))
+	nl := float64(len(nodes))
+	if nodeCPUs != nil {
+		nl = 0
+		for _, n := range nodes {
+			nl += float64(nodeCPUs[n])
+		}
+	}
+	slog.Debug(fmt.Sprintf("      nodeList length: %f", nl))
 	for _, n := range nodes {
 		slog.Debug(fmt.Sprintf("      node: %s", n))
-		partition, ok := nodePartitions.GetPartition(n)
+		nw := float64(1)
+		if nodeCPUs != nil {
+			nw = float64(nodeCPUs[n])
+		}
+		share, ok, err := nodePartitions.openUseShare(n, *openusePartitions, precedence)
+		if err != nil {
+			return 0.0, err
+		}
 		if !ok { // if partition not found, scale back the metric
-			slog.Debug("        partition not found, reducing nodeList length by 1")
-			nl -= 1
+			slog.Debug(fmt.Sprintf("        partition not found, reducing nodeList length by %f", nw))
+			nl -= nw
 			continue
 		}
-		slog.Debug(fmt.Sprintf("        partition: %s", partition))
-		isOpenuse := false
-		if slices.Contains(*openusePartitions, partition) {
-			isOpenuse = true
+		slog.Debug(fmt.Sprintf("        open use share: %f", share))
+		if category == types.JobCategoryOpen {
+			slog.Debug(fmt.Sprintf("        increasing count for open use by %f", nw*share))
+			c += nw * share
 		}
-		slog.Debug(fmt.Sprintf("        open use?: %v", isOpenuse))
-		if category == types.JobCategoryOpen && isOpenuse {
-			slog.Debug("        increasing count for open use by 1")
-			c += 1
-		}
-		if category == types.JobCategoryCondo && !isOpenuse {
-			slog.Debug("        increasing count for condo by 1")
-			c += 1
+		if category == types.JobCategoryCondo {
+			slog.Debug(fmt.Sprintf("        increasing count for condo by %f", nw*(1-share)))
+			c += nw * (1 - share)
 		}
 	}
 	if nl <= 0 {
 		slog.Debug("      length is 0 or lower due to missing partitions, just counting as 0.0 weight")
 		return 0.0, nil
 	}
-	wt := c / float64(nl)
+	wt := c / nl
 	slog.Debug(fmt.Sprintf("      resulting weight: %f", wt))
 	slog.Debug(fmt.Sprintf("    Finished Calculating Weight for %s: %s", string(category), nodeList))
 	return wt, nil
 }
 
-func calculateGPUsFromTRES(tres string) (int, error) {
-	if !strings.Contains(tres, "gres/gpu=") {
-		return 0, nil
-	}
-	for _, part := range strings.Split(tres, ",") {
-		if strings.Contains(part, "gres/gpu=") {
-			gpus, err := strconv.Atoi(strings.Split(part, "=")[1])
-			if err != nil {
-				return 0, fmt.Errorf("failed to parse gpus from tres: %v", err)
-			}
-			return gpus, nil
-		}
-	}
-	return 0, nil
-}
-
 func calculateWaitTimeHours(iso1 string, iso2 string) (float64, error) {
 	d1, err := time.Parse("2006-01-02T15:04:05", iso1)
 	if err != nil {
@@ -393,6 +564,44 @@ func calculateRunTimeHours(elapsed string) (float64, error) {
 	return float64(es) / 60 / 60, nil
 }
 
+// calculateProrateFraction returns the share of the job's elapsed time that
+// fell inside the processed day. Jobs still running at the end of the day
+// (End is Unknown) are clipped to the day's end.
+func calculateProrateFraction(day, start, end, elapsed string) (float64, error) {
+	windowStart, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return 0.0, fmt.Errorf("failed to parse process day: %v", err)
+	}
+	windowEnd := windowStart.AddDate(0, 0, 1)
+	s, err := time.ParseInLocation("2006-01-02T15:04:05", start, time.Local)
+	if err != nil {
+		return 0.0, fmt.Errorf("failed to parse start time: %v", err)
+	}
+	e, err := time.ParseInLocation("2006-01-02T15:04:05", end, time.Local)
+	if err != nil {
+		e = windowEnd
+	}
+	es, err := parseElapsedToSeconds(elapsed)
+	if err != nil {
+		return 0.0, err
+	}
+	if es == 0 {
+		return 1.0, nil
+	}
+	if s.Before(windowStart) {
+		s = windowStart
+	}
+	if e.After(windowEnd) {
+		e = windowEnd
+	}
+	overlap := e.Sub(s).Seconds()
+	if overlap <= 0 {
+		return 0.0, nil
+	}
+	// elapsed excludes time suspended, so never bill more than it
+	return min(overlap/float64(es), 1.0), nil
+}
+
 func calculateComputeHours(processors int, weight float64, elapsed string) (float64, error) {
 	slog.Debug("  Starting Calculation of Compute Hours")
 	slog.Debug(fmt.Sprintf("    processors: %d", processors))
@@ -409,14 +618,12 @@ func calculateComputeHours(processors int, weight float64, elapsed string) (floa
 }
 
 func getJobState(stateString string) (types.JobState, error) {
-	if stateString == "COMPLETED" {
-		return types.JobStateCompleted, nil
-	}
-	if stateString == "FAILED" {
-		return types.JobStateFailed, nil
-	}
-	if strings.Contains(stateString, "CANCELLED") {
-		return types.JobStateCancelled, nil
+	// cancelled jobs are reported as "CANCELLED by <uid>"
+	name, _, _ := strings.Cut(stateString, " ")
+	for _, s := range append(types.TerminalJobStates(), types.JobStateRunning) {
+		if name == s.SlurmName() {
+			return s, nil
+		}
 	}
 	return types.JobStateUnknown, fmt.Errorf("failed to find job state from string: %s", stateString)
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"github.com/lcrownover/process-job-stats-go/internal/types"
@@ -13,6 +14,8 @@ import (
 
 type RawJobData struct {
 	Jobs []string
+	// Steps holds the job step records, only populated in step mode
+	Steps []string
 }
 
 func NewRawJobData(ctx context.Context) (*RawJobData, error) {
@@ -29,30 +32,86 @@ func NewRawJobData(ctx context.Context) (*RawJobData, error) {
 	endTime := fmt.Sprintf("%sT23:59:59", *processDayDate)
 	slog.Debug(fmt.Sprintf("    date range: %s -> %s", startTime, endTime))
 
+	states, err := billedJobStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stateCodes := []string{}
+	for _, s := range states {
+		stateCodes = append(stateCodes, s.SlurmCode())
+	}
+
+	// -X limits sacct to the job allocations, step mode wants the steps too
+	stepMode, _ := ctx.Value(types.StepModeKey).(bool)
+	allocFlag := "-X "
+	if stepMode {
+		allocFlag = ""
+	}
+
 	sacctBin := fmt.Sprintf("%s/sacct", slurmBinDir)
 	cmd := exec.Command(
 		"bash",
 		"-c",
-		fmt.Sprintf("%s -X -P -n --starttime='%s' --endtime='%s' --state=F,CD,CA --format=JobID,JobName,User,Account,Partition,Elapsed,NNodes,NCPUS,AllocTRES,Submit,Start,End,Nodelist,State", sacctBin, startTime, endTime),
+		fmt.Sprintf("%s %s-P -n --starttime='%s' --endtime='%s' --state=%s --format=JobID,JobName,User,Account,Partition,Elapsed,NNodes,NCPUS,AllocTRES,Submit,Start,End,Nodelist,State,QOS", sacctBin, allocFlag, startTime, endTime, strings.Join(stateCodes, ",")),
 	)
 	var outb, errb bytes.Buffer
 	cmd.Stdout = &outb
 	cmd.Stderr = &errb
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		return nil, fmt.Errorf("failed to run command: %v: %v", err, errb.String())
 	}
 
+	// --state=R selects every job that was running during the window, even
+	// the ones that have since ended in a state that isn't billed
 	stdoutStr := outb.String()
 	lines := []string{}
 	for _, l := range strings.Split(stdoutStr, "\n") {
-		if strings.TrimSpace(l) != "" {
-			lines = append(lines, l)
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		jobID, _, _ := strings.Cut(l, "|")
+		if !strings.Contains(jobID, ".") && !recordInStates(l, states) {
+			continue
 		}
+		lines = append(lines, l)
 	}
 
 	slog.Debug("  Finished: Getting jobs from sacct")
-	return &RawJobData{
-		Jobs: lines,
-	}, nil
+	return splitRawJobData(ctx, lines), nil
+}
+
+// billedJobStates are the configured job states, plus running in prorate
+// mode. With a time window and --state, sacct selects finished jobs by when
+// they ended, so a job that ran for a week would only be returned on its last
+// day. Asking for running jobs too returns every job that ran on the day.
+func billedJobStates(ctx context.Context) ([]types.JobState, error) {
+	states, _ := ctx.Value(types.JobStatesKey).([]types.JobState)
+	if len(states) == 0 {
+		return nil, fmt.Errorf("failed to find job states in context")
+	}
+	if prorate, _ := ctx.Value(types.ProrateKey).(bool); prorate {
+		states = append(slices.Clone(states), types.JobStateRunning)
+	}
+	return states, nil
+}
+
+// splitRawJobData separates the job step records from the allocations,
+// dropping the steps unless step mode is enabled.
+func splitRawJobData(ctx context.Context, records []string) *RawJobData {
+	stepMode, _ := ctx.Value(types.StepModeKey).(bool)
+	rjd := &RawJobData{
+		Jobs: []string{},
+	}
+	for _, r := range records {
+		jobID, _, _ := strings.Cut(r, "|")
+		if !strings.Contains(jobID, ".") {
+			rjd.Jobs = append(rjd.Jobs, r)
+			continue
+		}
+		if stepMode {
+			rjd.Steps = append(rjd.Steps, r)
+		}
+	}
+	return rjd
 }
@@ -0,0 +1,36 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+// JobError is returned by NewJob and records which stage of processing the
+// job failed at, so rejected records can be sorted out and reprocessed.
+type JobError struct {
+	Stage types.JobStage
+	Err   error
+}
+
+func newJobError(stage types.JobStage, format string, a ...any) *JobError {
+	return &JobError{Stage: stage, Err: fmt.Errorf(format, a...)}
+}
+
+func (e *JobError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *JobError) Unwrap() error {
+	return e.Err
+}
+
+// JobErrorStage returns the stage an error from NewJob failed at.
+func JobErrorStage(err error) types.JobStage {
+	var je *JobError
+	if errors.As(err, &je) {
+		return je.Stage
+	}
+	return types.JobStageUnknown
+}
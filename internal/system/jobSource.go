@@ -0,0 +1,161 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+// JobSource produces the pipe-delimited sacct records that NewJob consumes
+// for the process day stored in the context.
+type JobSource interface {
+	Jobs(ctx context.Context) (*RawJobData, error)
+}
+
+type sacctJobSource struct{}
+
+// NewSacctJobSource returns a JobSource that queries sacct directly.
+func NewSacctJobSource() JobSource {
+	return &sacctJobSource{}
+}
+
+func (s *sacctJobSource) Jobs(ctx context.Context) (*RawJobData, error) {
+	return NewRawJobData(ctx)
+}
+
+type fileJobSource struct {
+	lines []string
+}
+
+// NewFileJobSource returns a JobSource that reads saved `sacct -P` output
+// from path, or from stdin when path is "-". The records must use the same
+// --format as NewRawJobData. The input is read once up front so it can be
+// shared across several process days.
+func NewFileJobSource(path string) (JobSource, error) {
+	slog.Debug(fmt.Sprintf("  Starting: Reading jobs from %s", path))
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open input file: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		l := scanner.Text()
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		// saved without -n, skip the header row
+		if strings.HasPrefix(l, "JobID|") {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %v", err)
+	}
+
+	slog.Debug(fmt.Sprintf("  Finished: Reading %d jobs from %s", len(lines), path))
+	return &fileJobSource{
+		lines: lines,
+	}, nil
+}
+
+// Jobs returns the saved records that ended on the process day in one of the
+// included states, which mirrors the --starttime/--endtime and --state
+// filters used when querying sacct: with --state, sacct selects finished jobs
+// by their end time. In prorate mode every record that overlaps the day is
+// returned instead, including running jobs, so each day bills its share of
+// the job.
+func (s *fileJobSource) Jobs(ctx context.Context) (*RawJobData, error) {
+	processDayDate := ctx.Value(types.ProcessDayKey).(*string)
+	if processDayDate == nil {
+		return nil, fmt.Errorf("failed to find process day in context")
+	}
+	dayStart, err := time.Parse("2006-01-02", *processDayDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse process day: %v", err)
+	}
+	dayEnd := dayStart.Add(24 * time.Hour)
+	states, err := billedJobStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inWindow := recordEndsIn
+	if prorate, _ := ctx.Value(types.ProrateKey).(bool); prorate {
+		inWindow = recordOverlaps
+	}
+
+	records := []string{}
+	for _, l := range s.lines {
+		if inWindow(l, dayStart, dayEnd) && recordInStates(l, states) {
+			records = append(records, l)
+		}
+	}
+	return splitRawJobData(ctx, records), nil
+}
+
+// recordEndsIn checks the End field of a record against the window. Records
+// whose end can't be read are kept so NewJob can report them.
+func recordEndsIn(record string, windowStart, windowEnd time.Time) bool {
+	parts := strings.Split(record, "|")
+	if len(parts) < 12 {
+		return true
+	}
+	end, err := time.Parse("2006-01-02T15:04:05", parts[11])
+	if err != nil {
+		return true
+	}
+	return !end.Before(windowStart) && end.Before(windowEnd)
+}
+
+// recordOverlaps checks the Start and End fields of a record against the
+// window. A running job's End is Unknown, so it overlaps every window after
+// its start. Records whose times can't be read are kept so NewJob can report
+// them.
+func recordOverlaps(record string, windowStart, windowEnd time.Time) bool {
+	parts := strings.Split(record, "|")
+	if len(parts) < 12 {
+		return true
+	}
+	start, startErr := time.Parse("2006-01-02T15:04:05", parts[10])
+	end, endErr := time.Parse("2006-01-02T15:04:05", parts[11])
+	switch {
+	case startErr != nil && endErr != nil:
+		return true
+	case endErr != nil:
+		return start.Before(windowEnd)
+	case startErr != nil:
+		start = end
+	}
+	return start.Before(windowEnd) && !end.Before(windowStart)
+}
+
+// recordInStates checks the State field of a record against the included
+// states. Records whose state can't be read are kept so NewJob can report them.
+func recordInStates(record string, states []types.JobState) bool {
+	parts := strings.Split(record, "|")
+	if len(parts) < 14 {
+		return true
+	}
+	state, err := getJobState(parts[13])
+	if err != nil {
+		return true
+	}
+	return slices.Contains(states, state)
+}
@@ -0,0 +1,55 @@
+package system
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+func TestFileJobSourceJobs(t *testing.T) {
+	src := &fileJobSource{
+		lines: []string{
+			"1|job|u|a|compute|02:00:00|1|4|cpu=4|2025-01-02T00:00:00|2025-01-02T08:00:00|2025-01-02T10:00:00|n01|COMPLETED|normal",
+			"2|job|u|a|compute|2-00:00:00|1|4|cpu=4|2024-12-31T12:00:00|2025-01-01T00:00:00|2025-01-03T00:00:00|n01|COMPLETED|normal",
+			"3|job|u|a|compute|1-00:00:00|1|4|cpu=4|2025-01-01T00:00:00|2025-01-01T12:00:00|2025-01-02T12:00:00|n01|FAILED|normal",
+			"4|job|u|a|compute|01:00:00|1|4|cpu=4|2025-01-02T00:00:00|2025-01-02T01:00:00|2025-01-02T02:00:00|n01|CANCELLED by 0|normal",
+			"5|job|u|a|compute|01:00:00|1|4|cpu=4|2025-01-03T00:00:00|2025-01-03T01:00:00|2025-01-03T02:00:00|n01|COMPLETED|normal",
+			"6|job|u|a|compute|1-12:00:00|1|4|cpu=4|2025-01-01T00:00:00|2025-01-01T12:00:00|Unknown|n01|RUNNING|normal",
+		},
+	}
+	tests := []struct {
+		name    string
+		day     string
+		prorate bool
+		want    []string
+	}{
+		{"jobs that ended on the day", "2025-01-02", false, []string{"1", "3"}},
+		{"a job is only returned on its end day", "2025-01-03", false, []string{"2", "5"}},
+		{"nothing ended on the day", "2025-01-01", false, []string{}},
+		{"prorate returns jobs that overlap the day", "2025-01-02", true, []string{"1", "2", "3", "6"}},
+		{"prorate returns a long job on every day", "2025-01-01", true, []string{"2", "3", "6"}},
+		{"prorate keeps running jobs", "2025-01-05", true, []string{"6"}},
+		{"prorate skips running jobs before they started", "2024-12-31", true, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := tt.day
+			ctx := context.WithValue(context.Background(), types.ProcessDayKey, &day)
+			ctx = context.WithValue(ctx, types.JobStatesKey, []types.JobState{types.JobStateCompleted, types.JobStateFailed})
+			ctx = context.WithValue(ctx, types.ProrateKey, tt.prorate)
+			rjd, err := src.Jobs(ctx)
+			if err != nil {
+				t.Fatalf("Jobs error: %v", err)
+			}
+			got := []string{}
+			for _, r := range rjd.Jobs {
+				got = append(got, r[:1])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Jobs(%s) = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package system
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateProrateFraction(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   string
+		end     string
+		elapsed string
+		want    float64
+	}{
+		{"runs within the day", "2025-01-02T08:00:00", "2025-01-02T10:00:00", "02:00:00", 1},
+		{"starts before the day", "2025-01-01T12:00:00", "2025-01-02T12:00:00", "1-00:00:00", 0.5},
+		{"ends after the day", "2025-01-02T18:00:00", "2025-01-03T06:00:00", "12:00:00", 0.5},
+		{"runs through the day", "2025-01-01T00:00:00", "2025-01-04T00:00:00", "3-00:00:00", 1.0 / 3},
+		{"still running", "2025-01-01T00:00:00", "Unknown", "2-00:00:00", 0.5},
+		{"ended before the day", "2024-12-31T00:00:00", "2025-01-01T00:00:00", "1-00:00:00", 0},
+		{"starts after the day", "2025-01-03T00:00:00", "2025-01-03T01:00:00", "01:00:00", 0},
+		{"suspended time isn't billed twice", "2025-01-02T00:00:00", "2025-01-02T04:00:00", "02:00:00", 1},
+		{"no elapsed time", "2025-01-02T00:00:00", "2025-01-02T00:00:00", "00:00:00", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calculateProrateFraction("2025-01-02", tt.start, tt.end, tt.elapsed)
+			if err != nil {
+				t.Fatalf("calculateProrateFraction error: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("calculateProrateFraction = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateProrateFractionErrors(t *testing.T) {
+	tests := []struct {
+		day, start, elapsed string
+	}{
+		{"2025-01", "2025-01-02T00:00:00", "01:00:00"},
+		{"2025-01-02", "Unknown", "01:00:00"},
+		{"2025-01-02", "2025-01-02T00:00:00", "one hour"},
+	}
+	for _, tt := range tests {
+		if got, err := calculateProrateFraction(tt.day, tt.start, "Unknown", tt.elapsed); err == nil {
+			t.Errorf("calculateProrateFraction(%q, %q, %q) = %f, want error", tt.day, tt.start, tt.elapsed, got)
+		}
+	}
+}
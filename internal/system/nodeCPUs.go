@@ -0,0 +1,118 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+// getJobNodeCPUs asks scontrol for the CPUs a job was allocated on each of
+// the nodes in its expanded nodeList. Slurm only keeps this detail for a few
+// minutes after a job ends, so callers should fall back to counting nodes
+// when it fails.
+func getJobNodeCPUs(ctx context.Context, jobID, nodeList string) (map[string]int, error) {
+	slog.Debug(fmt.Sprintf("  Started: Getting per node cpus for job %s", jobID))
+	slurmBinDir := ctx.Value(types.SlurmBinDirKey)
+	if slurmBinDir == nil {
+		return nil, fmt.Errorf("failed to find slurm bin dir in context")
+	}
+	scontrolBin := fmt.Sprintf("%s/scontrol", slurmBinDir)
+	cmd := exec.Command(
+		"bash",
+		"-c",
+		fmt.Sprintf("%s show job -d %s", scontrolBin, jobID),
+	)
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", errb.String())
+	}
+	nodeCPUs, err := parseJobNodeCPUs(outb.String())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNodeCPUs(nodeCPUs, nodeList); err != nil {
+		return nil, err
+	}
+	slog.Debug(fmt.Sprintf("  Finished: Getting per node cpus for job %s: %v", jobID, nodeCPUs))
+	return nodeCPUs, nil
+}
+
+// parseJobNodeCPUs reads the detail lines of `scontrol show job -d`, e.g.
+//
+//	Nodes=n[01-02] CPU_IDs=0-3,8 Mem=8000 GRES=
+func parseJobNodeCPUs(detail string) (map[string]int, error) {
+	nodeCPUs := make(map[string]int)
+	for _, line := range strings.Split(detail, "\n") {
+		var nodes, cpuIDs string
+		for _, f := range strings.Fields(line) {
+			if v, ok := strings.CutPrefix(f, "Nodes="); ok {
+				nodes = v
+			}
+			if v, ok := strings.CutPrefix(f, "CPU_IDs="); ok {
+				cpuIDs = v
+			}
+		}
+		if nodes == "" || cpuIDs == "" {
+			continue
+		}
+		hosts, err := ExpandHostList(nodes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand detail nodes: %v", err)
+		}
+		cpus, err := countCPUIDs(cpuIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hosts {
+			nodeCPUs[h] += cpus
+		}
+	}
+	if len(nodeCPUs) == 0 {
+		return nil, fmt.Errorf("no per node cpus found in job detail")
+	}
+	return nodeCPUs, nil
+}
+
+// checkNodeCPUs makes sure the job detail has CPUs for every node of the job,
+// a node without any would get no weight and the job could go unbilled.
+func checkNodeCPUs(nodeCPUs map[string]int, nodeList string) error {
+	for _, n := range strings.Split(nodeList, ",") {
+		if nodeCPUs[n] <= 0 {
+			return fmt.Errorf("no cpus for node %s in job detail", n)
+		}
+	}
+	return nil
+}
+
+// countCPUIDs counts the cpus in a list of ids and ranges like 0-3,8
+func countCPUIDs(ids string) (int, error) {
+	count := 0
+	for _, r := range strings.Split(ids, ",") {
+		lo, hi, isRange := strings.Cut(r, "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse cpu ids %s: %v", ids, err)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(hi)
+			if err != nil {
+				return 0, fmt.Errorf("failed to parse cpu ids %s: %v", ids, err)
+			}
+		}
+		if end < start {
+			return 0, fmt.Errorf("failed to parse cpu ids %s: range %s is backwards", ids, r)
+		}
+		count += end - start + 1
+	}
+	return count, nil
+}
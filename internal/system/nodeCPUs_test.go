@@ -0,0 +1,99 @@
+package system
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseJobNodeCPUs(t *testing.T) {
+	tests := []struct {
+		name   string
+		detail string
+		want   map[string]int
+	}{
+		{
+			"single node",
+			"   Nodes=n01 CPU_IDs=0-3 Mem=8000 GRES=",
+			map[string]int{"n01": 4},
+		},
+		{
+			"node range shares the cpu ids",
+			"   Nodes=n[01-02] CPU_IDs=0-3,8 Mem=8000 GRES=",
+			map[string]int{"n01": 5, "n02": 5},
+		},
+		{
+			"a line per node",
+			"JobId=1 JobName=x\n   Nodes=n01 CPU_IDs=0-7 Mem=0 GRES=\n   Nodes=c01 CPU_IDs=0,2 Mem=0 GRES=\n",
+			map[string]int{"n01": 8, "c01": 2},
+		},
+		{
+			"lines without cpu ids are ignored",
+			"   NodeList=n01 BatchHost=n01\n   Nodes=n01 CPU_IDs=0 Mem=0 GRES=",
+			map[string]int{"n01": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJobNodeCPUs(tt.detail)
+			if err != nil {
+				t.Fatalf("parseJobNodeCPUs error: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseJobNodeCPUs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJobNodeCPUsErrors(t *testing.T) {
+	for _, detail := range []string{
+		"",
+		"JobId=1 NodeList=n01",
+		"   Nodes=n[01 CPU_IDs=0-3",
+		"   Nodes=n01 CPU_IDs=0-x",
+	} {
+		if got, err := parseJobNodeCPUs(detail); err == nil {
+			t.Errorf("parseJobNodeCPUs(%q) = %v, want error", detail, got)
+		}
+	}
+}
+
+func TestCountCPUIDs(t *testing.T) {
+	tests := []struct {
+		ids  string
+		want int
+	}{
+		{"0", 1},
+		{"0-3", 4},
+		{"0-3,8", 5},
+		{"0,2,4,6", 4},
+		{"0-1,16-17,32", 5},
+	}
+	for _, tt := range tests {
+		got, err := countCPUIDs(tt.ids)
+		if err != nil {
+			t.Errorf("countCPUIDs(%q) error: %v", tt.ids, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("countCPUIDs(%q) = %d, want %d", tt.ids, got, tt.want)
+		}
+	}
+	for _, ids := range []string{"", "a", "0-", "0-b", "1,,2", "3-0"} {
+		if got, err := countCPUIDs(ids); err == nil {
+			t.Errorf("countCPUIDs(%q) = %d, want error", ids, got)
+		}
+	}
+}
+
+func TestCheckNodeCPUs(t *testing.T) {
+	nodeCPUs := map[string]int{"n01": 4, "c01": 2, "c02": 0}
+	if err := checkNodeCPUs(nodeCPUs, "n01,c01"); err != nil {
+		t.Errorf("checkNodeCPUs for covered nodes error: %v", err)
+	}
+	for _, nodeList := range []string{"n01,c03", "c02"} {
+		if err := checkNodeCPUs(nodeCPUs, nodeList); err == nil {
+			t.Errorf("checkNodeCPUs(%q) succeeded, want error", nodeList)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+// NodeGres holds the typed GPUs each node has, used to find the GPU model of
+// jobs whose AllocTRES only has an untyped gres/gpu count.
+type NodeGres struct {
+	data map[string]map[string]int
+}
+
+func NewNodeGres(ctx context.Context) (*NodeGres, error) {
+	slog.Debug("  Starting: Getting Node -> GPU associations")
+	slurmBinDir := ctx.Value(types.SlurmBinDirKey)
+	if slurmBinDir == nil {
+		return nil, fmt.Errorf("failed to find slurm bin dir in context")
+	}
+	sinfoBin := fmt.Sprintf("%s/sinfo", slurmBinDir)
+	cmd := exec.Command(
+		"bash",
+		"-c",
+		fmt.Sprintf("%s -N -h -o '%%n|%%G'", sinfoBin),
+	)
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", errb.String())
+	}
+
+	m := make(map[string]map[string]int)
+	for _, line := range strings.Split(outb.String(), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		node, gres, ok := strings.Cut(line, "|")
+		if !ok {
+			continue
+		}
+		node = strings.TrimSpace(node)
+		gpus := parseNodeGPUTypes(strings.TrimSpace(gres))
+		if len(gpus) == 0 {
+			continue
+		}
+		slog.Debug(fmt.Sprintf("    Adding node->gpus: %s->%v", node, gpus))
+		m[node] = gpus
+	}
+
+	slog.Debug("  Finished: Getting Node -> GPU associations")
+	return &NodeGres{
+		data: m,
+	}, nil
+}
+
+// gresSocketsRe matches the socket affinity sinfo appends to a gres, e.g.
+// the (S:0-1) of gpu:a100:4(S:0-1), which may itself hold commas
+var gresSocketsRe = regexp.MustCompile(`\([^)]*\)`)
+
+// parseNodeGPUTypes reads the typed GPU counts from a node's gres, e.g.
+// gpu:a100:4(S:0-1),gpu:v100:2. Untyped GPUs like gpu:4 are skipped.
+func parseNodeGPUTypes(gres string) map[string]int {
+	gpus := make(map[string]int)
+	for _, g := range strings.Split(gresSocketsRe.ReplaceAllString(gres, ""), ",") {
+		p := strings.Split(g, ":")
+		if len(p) != 3 || p[0] != "gpu" {
+			continue
+		}
+		n, err := strconv.Atoi(p[2])
+		if err != nil {
+			continue
+		}
+		gpus[p[1]] += n
+	}
+	return gpus
+}
+
+// GetGPUTypes returns the typed GPU counts of a node.
+func (ng *NodeGres) GetGPUTypes(node string) (map[string]int, bool) {
+	g, ok := ng.data[node]
+	return g, ok
+}
+
+// jobGPUTypes guesses the GPU model of a job from its nodes. It only returns
+// a model when every node with typed GPUs has the same single model.
+func (ng *NodeGres) jobGPUTypes(nodeList string, gpus int) map[string]int {
+	model := ""
+	for _, node := range strings.Split(nodeList, ",") {
+		g, ok := ng.GetGPUTypes(node)
+		if !ok {
+			continue
+		}
+		if len(g) != 1 {
+			return nil
+		}
+		for m := range g {
+			if model != "" && m != model {
+				return nil
+			}
+			model = m
+		}
+	}
+	if model == "" {
+		return nil
+	}
+	return map[string]int{model: gpus}
+}
@@ -0,0 +1,56 @@
+package system
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseNodeGPUTypes(t *testing.T) {
+	tests := []struct {
+		gres string
+		want map[string]int
+	}{
+		{"gpu:a100:4", map[string]int{"a100": 4}},
+		{"gpu:a100:4(S:0-1)", map[string]int{"a100": 4}},
+		{"gpu:a100:2(S:0,1),gpu:v100:2(S:0)", map[string]int{"a100": 2, "v100": 2}},
+		{"gpu:4", map[string]int{}},
+		{"gpu:a100:2,shard:a100:8", map[string]int{"a100": 2}},
+		{"(null)", map[string]int{}},
+	}
+	for _, tt := range tests {
+		if got := parseNodeGPUTypes(tt.gres); !maps.Equal(got, tt.want) {
+			t.Errorf("parseNodeGPUTypes(%q) = %v, want %v", tt.gres, got, tt.want)
+		}
+	}
+}
+
+func TestJobGPUTypes(t *testing.T) {
+	ng := &NodeGres{
+		data: map[string]map[string]int{
+			"gpu01": {"a100": 4},
+			"gpu02": {"a100": 4},
+			"gpu03": {"h100": 8},
+			"gpu04": {"a100": 2, "v100": 2},
+		},
+	}
+	tests := []struct {
+		name     string
+		nodeList string
+		want     map[string]int
+	}{
+		{"single node", "gpu01", map[string]int{"a100": 2}},
+		{"nodes with the same model", "gpu01,gpu02", map[string]int{"a100": 2}},
+		{"nodes without typed gpus are ignored", "cpu01,gpu03", map[string]int{"h100": 2}},
+		{"nodes with different models", "gpu01,gpu03", nil},
+		{"node with several models", "gpu04", nil},
+		{"no typed nodes", "cpu01,cpu02", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ng.jobGPUTypes(tt.nodeList, 2)
+			if !maps.Equal(got, tt.want) || (got == nil) != (tt.want == nil) {
+				t.Errorf("jobGPUTypes(%q) = %v, want %v", tt.nodeList, got, tt.want)
+			}
+		})
+	}
+}
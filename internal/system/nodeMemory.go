@@ -0,0 +1,97 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+// NodeMemory holds the memory per core of each node in GB, used to charge
+// memory heavy jobs for the cores their memory keeps others from using.
+type NodeMemory struct {
+	data map[string]float64
+}
+
+func NewNodeMemory(ctx context.Context) (*NodeMemory, error) {
+	slog.Debug("  Starting: Getting Node -> Memory per core associations")
+	slurmBinDir := ctx.Value(types.SlurmBinDirKey)
+	if slurmBinDir == nil {
+		return nil, fmt.Errorf("failed to find slurm bin dir in context")
+	}
+	sinfoBin := fmt.Sprintf("%s/sinfo", slurmBinDir)
+	cmd := exec.Command(
+		"bash",
+		"-c",
+		fmt.Sprintf("%s -N -h -o '%%n|%%c|%%m'", sinfoBin),
+	)
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", errb.String())
+	}
+
+	m := make(map[string]float64)
+	for _, line := range strings.Split(outb.String(), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		p := strings.Split(line, "|")
+		if len(p) != 3 {
+			return nil, fmt.Errorf("failed to parse sinfo line: %s", line)
+		}
+		node := strings.TrimSpace(p[0])
+		cpus, err := strconv.Atoi(strings.TrimSpace(p[1]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cpus for node %s: %v", node, err)
+		}
+		// sinfo reports memory in MB, and may add a + when it varies
+		memMB, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(p[2]), "+"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse memory for node %s: %v", node, err)
+		}
+		if cpus == 0 {
+			continue
+		}
+		perCore := memMB / 1024 / float64(cpus)
+		slog.Debug(fmt.Sprintf("    Adding node->memory per core: %s->%fGB", node, perCore))
+		m[node] = perCore
+	}
+
+	slog.Debug("  Finished: Getting Node -> Memory per core associations")
+	return &NodeMemory{
+		data: m,
+	}, nil
+}
+
+func (nm *NodeMemory) GetMemPerCoreGB(node string) (float64, bool) {
+	g, ok := nm.data[node]
+	return g, ok
+}
+
+// effectiveCPUs is the larger of the allocated CPUs and the cores the job's
+// memory fills at the mean memory per core of its nodes. Jobs on nodes with
+// no memory information are charged their CPUs.
+func (nm *NodeMemory) effectiveCPUs(nodeList string, cpus int, memGB float64) int {
+	total := 0.0
+	count := 0
+	for _, node := range strings.Split(nodeList, ",") {
+		if g, ok := nm.GetMemPerCoreGB(node); ok && g > 0 {
+			total += g
+			count++
+		}
+	}
+	if count == 0 {
+		return cpus
+	}
+	memCores := int(math.Ceil(memGB / (total / float64(count))))
+	return max(cpus, memCores)
+}
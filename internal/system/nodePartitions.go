@@ -6,13 +6,16 @@ import (
 	"fmt"
 	"log/slog"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"github.com/lcrownover/process-job-stats-go/internal/types"
 )
 
+// NodePartitions holds the partitions each node is in, other than the
+// preempt partition that spans every node.
 type NodePartitions struct {
-	data map[string]string
+	data map[string][]string
 }
 
 func NewNodePartitions(ctx context.Context) (*NodePartitions, error) {
@@ -21,6 +24,10 @@ func NewNodePartitions(ctx context.Context) (*NodePartitions, error) {
 	if slurmBinDir == nil {
 		return nil, fmt.Errorf("failed to find slurm bin dir in context")
 	}
+	preemptPartition := ctx.Value(types.PreemptPartitionKey)
+	if preemptPartition == nil {
+		return nil, fmt.Errorf("failed to find preempt partition in context")
+	}
 	sinfoBin := fmt.Sprintf("%s/sinfo", slurmBinDir)
 	cmd := exec.Command(
 		"bash",
@@ -44,17 +51,23 @@ func NewNodePartitions(ctx context.Context) (*NodePartitions, error) {
 		lines = append(lines, l)
 	}
 
-	m := make(map[string]string)
+	m := make(map[string][]string)
 
 	for _, line := range lines {
 		p := strings.Split(line, ",")
 		node := strings.TrimSpace(p[0])
 		partition := strings.TrimSpace(p[1])
-		if partition == "preempt" {
+		if partition == preemptPartition {
+			continue
+		}
+		if slices.Contains(m[node], partition) {
 			continue
 		}
 		slog.Debug(fmt.Sprintf("    Adding node->partition: %s->%s", node, partition))
-		m[node] = partition
+		m[node] = append(m[node], partition)
+	}
+	for _, partitions := range m {
+		slices.Sort(partitions)
 	}
 
 	slog.Debug("  Finished: Getting Node -> Partition associations")
@@ -63,7 +76,51 @@ func NewNodePartitions(ctx context.Context) (*NodePartitions, error) {
 	}, nil
 }
 
-func (np *NodePartitions) GetPartition(node string) (string, bool) {
+// NodePartitionsFromMap builds the node partitions from a saved map, see Map.
+func NodePartitionsFromMap(m map[string][]string) *NodePartitions {
+	return &NodePartitions{
+		data: m,
+	}
+}
+
+// Map returns the node to partitions map, e.g. to save it.
+func (np *NodePartitions) Map() map[string][]string {
+	return np.data
+}
+
+// GetPartitions returns the sorted partitions of a node.
+func (np *NodePartitions) GetPartitions(node string) ([]string, bool) {
 	p, ok := np.data[node]
 	return p, ok
 }
+
+// openUseShare is how much of a node counts as open-use, from 0 for a condo
+// node to 1 for an open-use one. Nodes in both kinds of partition are decided
+// by the precedence rule.
+func (np *NodePartitions) openUseShare(node string, openusePartitions []string, precedence string) (float64, bool, error) {
+	partitions, ok := np.data[node]
+	if !ok || len(partitions) == 0 {
+		return 0, false, nil
+	}
+	open := 0
+	for _, p := range partitions {
+		if slices.Contains(openusePartitions, p) {
+			open++
+		}
+	}
+	switch {
+	case open == len(partitions):
+		return 1, true, nil
+	case open == 0:
+		return 0, true, nil
+	}
+	switch precedence {
+	case types.NodePrecedenceOpenUse:
+		return 1, true, nil
+	case types.NodePrecedenceCondo:
+		return 0, true, nil
+	case types.NodePrecedenceShared:
+		return float64(open) / float64(len(partitions)), true, nil
+	}
+	return 0, false, fmt.Errorf("unknown node precedence: %s", precedence)
+}
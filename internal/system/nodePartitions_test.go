@@ -0,0 +1,98 @@
+package system
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+var testOpenUsePartitions = []string{"compute", "gpu"}
+
+// testNodePartitions has an open-use node, a condo node, and mixed nodes in
+// one open-use and one condo partition and in two open-use and one condo.
+func testNodePartitions() *NodePartitions {
+	return NodePartitionsFromMap(map[string][]string{
+		"open01":  {"compute"},
+		"condo01": {"kern"},
+		"mixed01": {"compute", "kern"},
+		"mixed02": {"compute", "gpu", "kern"},
+	})
+}
+
+func TestOpenUseShare(t *testing.T) {
+	np := testNodePartitions()
+	tests := []struct {
+		node       string
+		precedence string
+		want       float64
+		wantOK     bool
+	}{
+		{"open01", types.NodePrecedenceCondo, 1, true},
+		{"condo01", types.NodePrecedenceOpenUse, 0, true},
+		{"mixed01", types.NodePrecedenceCondo, 0, true},
+		{"mixed01", types.NodePrecedenceOpenUse, 1, true},
+		{"mixed01", types.NodePrecedenceShared, 0.5, true},
+		{"mixed02", types.NodePrecedenceShared, 2.0 / 3, true},
+		{"missing01", types.NodePrecedenceCondo, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.node+"/"+tt.precedence, func(t *testing.T) {
+			got, ok, err := np.openUseShare(tt.node, testOpenUsePartitions, tt.precedence)
+			if err != nil {
+				t.Fatalf("openUseShare error: %v", err)
+			}
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("openUseShare = %f, %v, want %f, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestOpenUseShareUnknownPrecedence(t *testing.T) {
+	if _, _, err := testNodePartitions().openUseShare("mixed01", testOpenUsePartitions, "last"); err == nil {
+		t.Error("openUseShare with an unknown precedence succeeded, want error")
+	}
+	// nodes that aren't mixed don't need the precedence
+	if _, _, err := testNodePartitions().openUseShare("open01", testOpenUsePartitions, "last"); err != nil {
+		t.Errorf("openUseShare for an open-use node error: %v", err)
+	}
+}
+
+func TestCalculateWeights(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodeList    string
+		precedence  string
+		nodeCPUs    map[string]int
+		wantOpenUse float64
+		wantCondo   float64
+	}{
+		{"open-use node", "open01", types.NodePrecedenceCondo, nil, 1, 0},
+		{"condo node", "condo01", types.NodePrecedenceCondo, nil, 0, 1},
+		{"open-use and condo nodes", "open01,condo01", types.NodePrecedenceCondo, nil, 0.5, 0.5},
+		{"mixed node counts as condo", "open01,mixed01", types.NodePrecedenceCondo, nil, 0.5, 0.5},
+		{"mixed node counts as open-use", "open01,mixed01", types.NodePrecedenceOpenUse, nil, 1, 0},
+		{"mixed node is shared", "condo01,mixed01", types.NodePrecedenceShared, nil, 0.25, 0.75},
+		{"nodes without partitions are left out", "open01,missing01", types.NodePrecedenceCondo, nil, 1, 0},
+		{"only unknown nodes", "missing01", types.NodePrecedenceCondo, nil, 0, 0},
+		{"weighted by cpus", "open01,condo01", types.NodePrecedenceCondo, map[string]int{"open01": 2, "condo01": 62}, 2.0 / 64, 62.0 / 64},
+		{"cpus on a shared node", "open01,mixed02", types.NodePrecedenceShared, map[string]int{"open01": 4, "mixed02": 6}, 0.8, 0.2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openUse := testOpenUsePartitions
+			ctx := context.WithValue(context.Background(), types.OpenUsePartitionsKey, &openUse)
+			ctx = context.WithValue(ctx, types.NodePartitionsKey, testNodePartitions())
+			ctx = context.WithValue(ctx, types.NodePrecedenceKey, tt.precedence)
+			gotOpenUse, gotCondo, err := calculateWeights(ctx, tt.nodeList, tt.nodeCPUs)
+			if err != nil {
+				t.Fatalf("calculateWeights error: %v", err)
+			}
+			if math.Abs(gotOpenUse-tt.wantOpenUse) > 1e-9 || math.Abs(gotCondo-tt.wantCondo) > 1e-9 {
+				t.Errorf("calculateWeights = %f, %f, want %f, %f", gotOpenUse, gotCondo, tt.wantOpenUse, tt.wantCondo)
+			}
+		})
+	}
+}
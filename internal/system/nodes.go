@@ -49,6 +49,32 @@ func expandNodeList(ctx context.Context, nlc *nodeListCache, nodeList string) (s
 		slog.Debug(fmt.Sprintf("    Found nodelist in cache: %s->%s", nodeList, nodes))
 		return nodes, nil
 	}
+	hosts, err := ExpandHostList(nodeList)
+	if err != nil {
+		fallback, _ := ctx.Value(types.ScontrolFallbackKey).(bool)
+		if !fallback {
+			return "", fmt.Errorf("failed to expand nodelist: %v", err)
+		}
+		slog.Debug(fmt.Sprintf("    Native expansion failed, falling back to scontrol: %v", err))
+		nodes, err = expandNodeListScontrol(ctx, nodeList)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		nodes = strings.Join(hosts, ",")
+	}
+
+	slog.Debug(fmt.Sprintf("    Writing nodelist to cache: %s->%s", nodeList, nodes))
+	nlc.Write(nodeList, nodes)
+
+	slog.Debug(fmt.Sprintf("    %v", nodes))
+	slog.Debug("  Finished: Expanding nodelist")
+	return nodes, nil
+}
+
+// expandNodeListScontrol asks slurm to expand the nodelist, only used when
+// the native parser can't handle the expression.
+func expandNodeListScontrol(ctx context.Context, nodeList string) (string, error) {
 	slurmBinDir := ctx.Value(types.SlurmBinDirKey)
 	if slurmBinDir == nil {
 		return "", fmt.Errorf("failed to find slurm bin dir in context")
@@ -74,12 +100,5 @@ func expandNodeList(ctx context.Context, nlc *nodeListCache, nodeList string) (s
 			lines = append(lines, l)
 		}
 	}
-	nodes = strings.Join(lines, ",")
-
-	slog.Debug(fmt.Sprintf("    Writing nodelist to cache: %s->%s", nodeList, nodes))
-	nlc.Write(nodeList, nodes)
-
-	slog.Debug(fmt.Sprintf("    %v", nodes))
-	slog.Debug("  Finished: Expanding nodelist")
-	return nodes, nil
+	return strings.Join(lines, ","), nil
 }
@@ -8,36 +8,70 @@ import (
 	"github.com/lcrownover/process-job-stats-go/internal/types"
 )
 
-var preemptWeight = 1
-
-// Service units are calculated at the following rates:
-// Open-Use job with GPU usage:				1CpuHour == 3 SU
-// Open-Use job on high memory nodes:		1CpuHour == 2 SU
-// Open-Use job on standard compute nodes: 	1CpuHour == 1 SU
-// Condo job:								0 SU
-// Preempt job:								(CpuHours*1 + GpuHours*3)*weight SU
-func calculateServiceUnits(category types.JobCategory, partition string, cpuHoursOpenUse, cpuHoursCondo, gpuHoursOpenUse, gpuHoursCondo float64) float64 {
-	// open use
-	// high memory partition -> return 2x open use cpus + 3x gpus
-	// else -> return open use cpus + 3x gpus
-	if category == types.JobCategoryOpen {
-		if slices.Contains([]string{"memory", "memorylong"}, partition) {
-			su := (cpuHoursOpenUse * 2) + (gpuHoursOpenUse * 3)
-			slog.Debug(fmt.Sprintf("    service units: high memory partition: %f", su))
-			return su
-		} else {
-			su := cpuHoursOpenUse + (gpuHoursOpenUse * 3)
-			slog.Debug(fmt.Sprintf("    service units: standard partition: %f", su))
-			return su
+// Service units are calculated from the first rule in the rate table that
+// matches the job:
+//
+//	SU = CpuHours*cpu_rate + GpuHours*gpu_rate
+//
+// where the hours are the job's open-use, condo or total hours depending on
+// the rule. When the rule has gpu_model_rates and the job's GPU models are
+// known, the GPU hours are split across the models and each part is charged
+// at its model's rate, falling back to gpu_rate. See config.Default for the
+// shipped table.
+func calculateServiceUnits(rules []types.ServiceUnitRule, j *Job) (float64, error) {
+	for i, r := range rules {
+		if !serviceUnitRuleMatches(r, j) {
+			continue
+		}
+		var cpuHours, gpuHours float64
+		switch r.Hours {
+		case "", types.ServiceUnitHoursOpenUse:
+			cpuHours, gpuHours = j.CPUHoursOpenUse, j.GPUHoursOpenUse
+		case types.ServiceUnitHoursCondo:
+			cpuHours, gpuHours = j.CPUHoursCondo, j.GPUHoursCondo
+		case types.ServiceUnitHoursTotal:
+			cpuHours, gpuHours = j.CPUHoursTotal, j.GPUHoursTotal
+		default:
+			return 0, fmt.Errorf("service unit rule %d has unknown hours: %s", i, r.Hours)
 		}
+		su := cpuHours*r.CPURate + gpuServiceUnits(r, j, gpuHours)
+		slog.Debug(fmt.Sprintf("    service units: rule %d: %f", i, su))
+		return su, nil
 	}
-	// condo -> return 0
-	if category == types.JobCategoryCondo {
-		slog.Debug("    service units: condo job: 0")
-		return 0
+	return 0, fmt.Errorf("no service unit rule matches job %s", j.JobID)
+}
+
+func gpuServiceUnits(r types.ServiceUnitRule, j *Job, gpuHours float64) float64 {
+	total := 0
+	for _, n := range j.GPUTypes {
+		total += n
+	}
+	if len(r.GPUModelRates) == 0 || total == 0 {
+		return gpuHours * r.GPURate
+	}
+	su := 0.0
+	for model, n := range j.GPUTypes {
+		rate, ok := r.GPUModelRates[model]
+		if !ok {
+			rate = r.GPURate
+		}
+		su += gpuHours * float64(n) / float64(total) * rate
 	}
-	// otherwise its preempt
-	su := ((cpuHoursOpenUse + cpuHoursCondo) * 1) + ((gpuHoursOpenUse + gpuHoursCondo) * 3) * float64(preemptWeight)
-	slog.Debug(fmt.Sprintf("    service units: preempt job: %f", su))
 	return su
 }
+
+func serviceUnitRuleMatches(r types.ServiceUnitRule, j *Job) bool {
+	if r.Category != "" && r.Category != j.Category {
+		return false
+	}
+	if len(r.Partitions) > 0 && !slices.Contains(r.Partitions, j.Partition) {
+		return false
+	}
+	if len(r.QOS) > 0 && !slices.Contains(r.QOS, j.QOS) {
+		return false
+	}
+	if r.GPU != nil && *r.GPU != (j.GPUs > 0) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package system
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/lcrownover/process-job-stats-go/internal/config"
+	"github.com/lcrownover/process-job-stats-go/internal/types"
+)
+
+// baselineServiceUnits is the hard coded formula the default rate table
+// replaced.
+func baselineServiceUnits(category types.JobCategory, partition string, cpuHoursOpenUse, cpuHoursCondo, gpuHoursOpenUse, gpuHoursCondo float64) float64 {
+	if category == types.JobCategoryOpen {
+		if slices.Contains([]string{"memory", "memorylong"}, partition) {
+			return (cpuHoursOpenUse * 2) + (gpuHoursOpenUse * 3)
+		}
+		return cpuHoursOpenUse + (gpuHoursOpenUse * 3)
+	}
+	if category == types.JobCategoryCondo {
+		return 0
+	}
+	return (cpuHoursOpenUse + cpuHoursCondo) + (gpuHoursOpenUse+gpuHoursCondo)*3
+}
+
+func TestCalculateServiceUnitsMatchesBaseline(t *testing.T) {
+	rates := config.Default().ServiceUnitRates
+	tests := []struct {
+		name      string
+		category  types.JobCategory
+		partition string
+		gpus      int
+	}{
+		{"open-use memory", types.JobCategoryOpen, "memory", 0},
+		{"open-use memory with gpus", types.JobCategoryOpen, "memory", 2},
+		{"open-use memorylong", types.JobCategoryOpen, "memorylong", 0},
+		{"open-use memorylong with gpus", types.JobCategoryOpen, "memorylong", 2},
+		{"open-use standard", types.JobCategoryOpen, "compute", 0},
+		{"open-use standard with gpus", types.JobCategoryOpen, "gpu", 4},
+		{"condo", types.JobCategoryCondo, "kern", 0},
+		{"condo with gpus", types.JobCategoryCondo, "kerngpu", 2},
+		{"preempt", types.JobCategoryPreempt, "preempt", 0},
+		{"preempt with gpus", types.JobCategoryPreempt, "preempt", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a job split across open-use and condo nodes, so preempt has
+			// to charge the total of both
+			j := &Job{
+				JobID:           "1",
+				Category:        tt.category,
+				Partition:       tt.partition,
+				GPUs:            tt.gpus,
+				CPUHoursOpenUse: 12,
+				CPUHoursCondo:   4,
+			}
+			if tt.gpus > 0 {
+				j.GPUHoursOpenUse = 3
+				j.GPUHoursCondo = 1
+			}
+			j.CPUHoursTotal = j.CPUHoursOpenUse + j.CPUHoursCondo
+			j.GPUHoursTotal = j.GPUHoursOpenUse + j.GPUHoursCondo
+			want := baselineServiceUnits(j.Category, j.Partition, j.CPUHoursOpenUse, j.CPUHoursCondo, j.GPUHoursOpenUse, j.GPUHoursCondo)
+			got, err := calculateServiceUnits(rates, j)
+			if err != nil {
+				t.Fatalf("calculateServiceUnits error: %v", err)
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Errorf("calculateServiceUnits = %f, want %f", got, want)
+			}
+		})
+	}
+}
+
+func TestCalculateServiceUnitsNoMatchingRule(t *testing.T) {
+	j := &Job{JobID: "1", Category: types.JobCategoryUnknown, CPUHoursOpenUse: 1}
+	if _, err := calculateServiceUnits(config.Default().ServiceUnitRates, j); err == nil {
+		t.Error("calculateServiceUnits for an unknown category job succeeded, want error")
+	}
+}
+
+func TestGPUServiceUnits(t *testing.T) {
+	r := types.ServiceUnitRule{
+		GPURate:       3,
+		GPUModelRates: map[string]float64{"h100": 6, "a100": 4},
+	}
+	tests := []struct {
+		name     string
+		rule     types.ServiceUnitRule
+		gpuTypes map[string]int
+		want     float64
+	}{
+		{"untyped gpus use gpu_rate", r, nil, 30},
+		{"no model rates use gpu_rate", types.ServiceUnitRule{GPURate: 3}, map[string]int{"h100": 2}, 30},
+		{"single model", r, map[string]int{"h100": 2}, 60},
+		{"unlisted model uses gpu_rate", r, map[string]int{"v100": 2}, 30},
+		{"hours split across models", r, map[string]int{"h100": 1, "a100": 1}, 5*6 + 5*4},
+		{"split by gpu count", r, map[string]int{"h100": 3, "v100": 1}, 7.5*6 + 2.5*3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &Job{GPUTypes: tt.gpuTypes}
+			if got := gpuServiceUnits(tt.rule, j, 10); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("gpuServiceUnits = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
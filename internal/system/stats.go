@@ -0,0 +1,15 @@
+package system
+
+import "sync/atomic"
+
+// RunStats counts what happened to the job records handed to the workers.
+// It's safe for concurrent use.
+type RunStats struct {
+	Processed atomic.Int64
+	Skipped   atomic.Int64
+	Failed    atomic.Int64
+}
+
+func NewRunStats() *RunStats {
+	return &RunStats{}
+}
@@ -0,0 +1,181 @@
+package system
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+type JobStepType string
+
+const (
+	JobStepTypeBatch       JobStepType = "batch"
+	JobStepTypeExtern      JobStepType = "extern"
+	JobStepTypeInteractive JobStepType = "interactive"
+	JobStepTypeNumbered    JobStepType = "numbered"
+)
+
+// JobStep is a single step of a job, e.g. 29148459.batch or 29148459_925.0,
+// linked to its parent allocation by JobID.
+type JobStep struct {
+	JobID     string
+	StepID    string
+	StepType  JobStepType
+	StepName  string
+	Elapsed   string
+	NodeCount int
+	CPUs      int
+	TRES      string
+	StartTime string
+	EndTime   string
+	NodeList  string
+	State     string
+
+	GPUs         int
+	CPUHours     float64
+	GPUHours     float64
+	RunTimeHours float64
+}
+
+// NewJobStep parses a step record, which uses the same sacct format as the
+// allocation records handed to NewJob.
+func NewJobStep(stepString string) (*JobStep, error) {
+	var err error
+	slog.Debug(fmt.Sprintf("    Parsing job step: %s", stepString))
+	parts := strings.Split(stepString, "|")
+	if len(parts) < 14 {
+		return nil, fmt.Errorf("step record has %d fields, expected at least 14", len(parts))
+	}
+	s := &JobStep{}
+	s.JobID, s.StepID, s.StepType, err = parseStepID(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	s.StepName = parts[1]
+	s.Elapsed = parts[5]
+	s.NodeCount, err = strconv.Atoi(parts[6])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse step nodes: %v", err)
+	}
+	s.CPUs, err = strconv.Atoi(parts[7])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse step cpus: %v", err)
+	}
+	s.TRES = parts[8]
+	s.StartTime = parts[10]
+	s.EndTime = parts[11]
+	s.NodeList = parts[12]
+	s.State = parts[13]
+
+	tres, err := ParseTRES(s.TRES)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse step tres: %v", err)
+	}
+	s.GPUs = tres.GPUs
+	s.RunTimeHours, err = calculateRunTimeHours(s.Elapsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate step run time hours: %v", err)
+	}
+	s.CPUHours = float64(s.CPUs) * s.RunTimeHours
+	s.GPUHours = float64(s.GPUs) * s.RunTimeHours
+	return s, nil
+}
+
+// parseStepID splits a step's JobID into the parent job and step.
+//
+//	29148459.batch     -> 29148459, batch
+//	29148459_925.0     -> 29148459_925, 0
+//	29148459.extern    -> 29148459, extern
+func parseStepID(id string) (string, string, JobStepType, error) {
+	jobID, stepID, ok := strings.Cut(id, ".")
+	if !ok || jobID == "" || stepID == "" {
+		return "", "", "", fmt.Errorf("invalid step id: %s", id)
+	}
+	switch stepID {
+	case "batch":
+		return jobID, stepID, JobStepTypeBatch, nil
+	case "extern":
+		return jobID, stepID, JobStepTypeExtern, nil
+	case "interactive":
+		return jobID, stepID, JobStepTypeInteractive, nil
+	}
+	if _, err := strconv.Atoi(stepID); err != nil {
+		return "", "", "", fmt.Errorf("invalid step id: %s", id)
+	}
+	return jobID, stepID, JobStepTypeNumbered, nil
+}
+
+// NewJobSteps parses step records and groups them by parent JobID. Records
+// that fail to parse are logged and skipped.
+func NewJobSteps(records []string) map[string][]*JobStep {
+	slog.Debug("  Starting: Parsing job steps")
+	steps := make(map[string][]*JobStep)
+	for _, r := range records {
+		s, err := NewJobStep(r)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to parse job step: %v", err))
+			continue
+		}
+		steps[s.JobID] = append(steps[s.JobID], s)
+	}
+	slog.Debug("  Finished: Parsing job steps")
+	return steps
+}
+
+// rollupSteps sums the usage of a job's steps. The extern step spans the
+// whole allocation rather than doing work, so it's counted but not summed.
+func rollupSteps(steps []*JobStep) (int, float64, float64) {
+	cpuHours := 0.0
+	gpuHours := 0.0
+	for _, s := range steps {
+		if s.StepType == JobStepTypeExtern {
+			continue
+		}
+		cpuHours += s.CPUHours
+		gpuHours += s.GPUHours
+	}
+	return len(steps), cpuHours, gpuHours
+}
+
+func JobStepKeys() []string {
+	return []string{
+		"JobID",
+		"StepID",
+		"StepType",
+		"StepName",
+		"Elapsed",
+		"NodeCount",
+		"CPUs",
+		"TRES",
+		"StartTime",
+		"EndTime",
+		"NodeList",
+		"State",
+		"GPUs",
+		"CPUHours",
+		"GPUHours",
+		"RunTimeHours",
+	}
+}
+
+func (s *JobStep) Fields() []string {
+	return []string{
+		s.JobID,
+		s.StepID,
+		string(s.StepType),
+		s.StepName,
+		s.Elapsed,
+		fmt.Sprintf("%d", s.NodeCount),
+		fmt.Sprintf("%d", s.CPUs),
+		s.TRES,
+		s.StartTime,
+		s.EndTime,
+		s.NodeList,
+		s.State,
+		fmt.Sprintf("%d", s.GPUs),
+		fmt.Sprintf("%f", s.CPUHours),
+		fmt.Sprintf("%f", s.GPUHours),
+		fmt.Sprintf("%f", s.RunTimeHours),
+	}
+}
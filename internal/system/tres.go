@@ -0,0 +1,126 @@
+package system
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TRES holds the trackable resources allocated to a job or step, parsed from
+// sacct's AllocTRES field, e.g.
+//
+//	billing=8,cpu=8,gres/gpu:a100=2,gres/gpu=2,mem=64G,node=1,license/matlab=1
+type TRES struct {
+	CPUs    int
+	MemGB   float64
+	Nodes   int
+	Billing int
+	// Energy is in joules, only set when slurm has an energy plugin
+	Energy int64
+	GPUs   int
+	// GPUTypes counts the GPUs by model, e.g. a100, only set when the
+	// cluster uses typed gres
+	GPUTypes map[string]int
+	// GRES counts any other generic resources, e.g. shard
+	GRES     map[string]int
+	Licenses map[string]int
+}
+
+// ParseTRES parses a comma separated TRES string. Resources it doesn't know,
+// like fs/disk or bb/*, are ignored.
+func ParseTRES(tres string) (*TRES, error) {
+	t := &TRES{
+		GPUTypes: map[string]int{},
+		GRES:     map[string]int{},
+		Licenses: map[string]int{},
+	}
+	untypedGPUs := -1
+	for _, part := range strings.Split(tres, ",") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("failed to parse tres entry: %s", part)
+		}
+		var err error
+		switch {
+		case key == "cpu":
+			t.CPUs, err = strconv.Atoi(value)
+		case key == "mem":
+			t.MemGB, err = parseTRESMemGB(value)
+		case key == "node":
+			t.Nodes, err = strconv.Atoi(value)
+		case key == "billing":
+			t.Billing, err = strconv.Atoi(value)
+		case key == "energy":
+			t.Energy, err = strconv.ParseInt(value, 10, 64)
+		case key == "gres/gpu":
+			untypedGPUs, err = strconv.Atoi(value)
+		case strings.HasPrefix(key, "gres/gpu:"):
+			t.GPUTypes[strings.TrimPrefix(key, "gres/gpu:")], err = strconv.Atoi(value)
+		case strings.HasPrefix(key, "gres/"):
+			t.GRES[strings.TrimPrefix(key, "gres/")], err = strconv.Atoi(value)
+		case strings.HasPrefix(key, "license/"):
+			t.Licenses[strings.TrimPrefix(key, "license/")], err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tres %s: %v", key, err)
+		}
+	}
+
+	// newer slurm versions list both gres/gpu and the typed gres/gpu:<model>
+	// counts, older ones and untyped clusters only one of them
+	if untypedGPUs >= 0 {
+		t.GPUs = untypedGPUs
+	} else {
+		for _, n := range t.GPUTypes {
+			t.GPUs += n
+		}
+	}
+	return t, nil
+}
+
+// GPUType is the GPU model of the allocation, with several models sorted and
+// comma separated. It's empty when the GPUs aren't typed.
+func (t *TRES) GPUType() string {
+	return gpuTypeName(t.GPUTypes)
+}
+
+func gpuTypeName(gpuTypes map[string]int) string {
+	return strings.Join(slices.Sorted(maps.Keys(gpuTypes)), ",")
+}
+
+// parseTRESMemGB converts a memory value to GB. Slurm reports memory in
+// binary units with a K, M, G, T or P suffix, and in MB without one.
+func parseTRESMemGB(v string) (float64, error) {
+	scale := 1.0 / 1024
+	if n := len(v); n > 0 {
+		switch v[n-1] {
+		case 'K':
+			scale = 1.0 / (1024 * 1024)
+		case 'M':
+			scale = 1.0 / 1024
+		case 'G':
+			scale = 1
+		case 'T':
+			scale = 1024
+		case 'P':
+			scale = 1024 * 1024
+		default:
+			if v[n-1] < '0' || v[n-1] > '9' {
+				return 0, fmt.Errorf("unknown memory unit: %s", v)
+			}
+		}
+		if v[n-1] < '0' || v[n-1] > '9' {
+			v = v[:n-1]
+		}
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	return f * scale, nil
+}
@@ -0,0 +1,118 @@
+package system
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseTRES(t *testing.T) {
+	tests := []struct {
+		name string
+		tres string
+		want TRES
+	}{
+		{
+			"cpu only",
+			"cpu=4,node=1",
+			TRES{CPUs: 4, Nodes: 1},
+		},
+		{
+			"typed and untyped gpus",
+			"billing=8,cpu=8,gres/gpu:a100=2,gres/gpu=2,mem=64G,node=1",
+			TRES{CPUs: 8, MemGB: 64, Nodes: 1, Billing: 8, GPUs: 2, GPUTypes: map[string]int{"a100": 2}},
+		},
+		{
+			"typed gpus only",
+			"cpu=8,gres/gpu:a100=1,gres/gpu:h100=2,node=1",
+			TRES{CPUs: 8, Nodes: 1, GPUs: 3, GPUTypes: map[string]int{"a100": 1, "h100": 2}},
+		},
+		{
+			"untyped gpus only",
+			"cpu=8,gres/gpu=4,node=2",
+			TRES{CPUs: 8, Nodes: 2, GPUs: 4},
+		},
+		{
+			"untyped count wins over typed",
+			"gres/gpu:a100=1,gres/gpu=2",
+			TRES{GPUs: 2, GPUTypes: map[string]int{"a100": 1}},
+		},
+		{
+			"other gres and licenses",
+			"cpu=2,gres/shard=3,license/matlab=1,license/ansys=2",
+			TRES{CPUs: 2, GRES: map[string]int{"shard": 3}, Licenses: map[string]int{"matlab": 1, "ansys": 2}},
+		},
+		{
+			"energy and unknown resources",
+			"cpu=1,energy=123456789012,fs/disk=1000,bb/datawarp=10G",
+			TRES{CPUs: 1, Energy: 123456789012},
+		},
+		{
+			"empty",
+			"",
+			TRES{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTRES(tt.tres)
+			if err != nil {
+				t.Fatalf("ParseTRES(%q) error: %v", tt.tres, err)
+			}
+			if got.CPUs != tt.want.CPUs || got.MemGB != tt.want.MemGB || got.Nodes != tt.want.Nodes ||
+				got.Billing != tt.want.Billing || got.Energy != tt.want.Energy || got.GPUs != tt.want.GPUs {
+				t.Errorf("ParseTRES(%q) = %+v, want %+v", tt.tres, *got, tt.want)
+			}
+			for name, pair := range map[string][2]map[string]int{
+				"GPUTypes": {got.GPUTypes, tt.want.GPUTypes},
+				"GRES":     {got.GRES, tt.want.GRES},
+				"Licenses": {got.Licenses, tt.want.Licenses},
+			} {
+				if !maps.Equal(pair[0], pair[1]) {
+					t.Errorf("ParseTRES(%q) %s = %v, want %v", tt.tres, name, pair[0], pair[1])
+				}
+			}
+		})
+	}
+}
+
+func TestParseTRESErrors(t *testing.T) {
+	for _, tres := range []string{
+		"cpu",
+		"cpu=four",
+		"cpu=4,node",
+		"gres/gpu=x",
+		"gres/gpu:a100=",
+		"license/matlab=one",
+		"mem=12X",
+		"mem=G",
+	} {
+		if got, err := ParseTRES(tres); err == nil {
+			t.Errorf("ParseTRES(%q) = %+v, want error", tres, *got)
+		}
+	}
+}
+
+func TestParseTRESMemGB(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"1048576K", 1},
+		{"512M", 0.5},
+		{"64G", 64},
+		{"1.5G", 1.5},
+		{"2T", 2048},
+		{"1P", 1024 * 1024},
+		{"2048", 2},
+	}
+	for _, tt := range tests {
+		got, err := parseTRESMemGB(tt.value)
+		if err != nil {
+			t.Errorf("parseTRESMemGB(%q) error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTRESMemGB(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package types
+
+// How the CPUs of a job are counted when charging it.
+const (
+	// ChargeModelCPUs charges the allocated CPUs
+	ChargeModelCPUs = "cpus"
+	// ChargeModelEffectiveCores charges the larger of the allocated CPUs and
+	// the cores the job's memory would take up at its nodes' memory per core
+	ChargeModelEffectiveCores = "effective_cores"
+)
@@ -0,0 +1,20 @@
+package types
+
+// JobStage is the step of turning a sacct record into a job that failed.
+type JobStage string
+
+const (
+	JobStageParse        JobStage = "parse"
+	JobStageNodeList     JobStage = "nodelist"
+	JobStageState        JobStage = "state"
+	JobStagePI           JobStage = "pi"
+	JobStageUser         JobStage = "user"
+	JobStageStorage      JobStage = "storage"
+	JobStageCategory     JobStage = "category"
+	JobStageWeight       JobStage = "weight"
+	JobStageTRES         JobStage = "tres"
+	JobStageTime         JobStage = "time"
+	JobStageProrate      JobStage = "prorate"
+	JobStageServiceUnits JobStage = "service_units"
+	JobStageUnknown      JobStage = "unknown"
+)
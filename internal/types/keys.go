@@ -12,13 +12,88 @@ const (
 	NodePartitionsKey
 	NodeListCacheKey
 	UserListCacheKey
+	ScontrolFallbackKey
+	GpfsFilesystemKey
+	ProjectsDirKey
+	PreemptPartitionKey
+	ServiceUnitRatesKey
+	StepModeKey
+	JobStepsKey
+	ProrateKey
+	JobStatesKey
+	NodeGresKey
+	ChargeModelKey
+	NodeMemoryKey
+	ScontrolJobDetailKey
+	NodePrecedenceKey
 )
 
 type JobState string
 
 const (
-	JobStateCompleted JobState = "completed"
-	JobStateCancelled JobState = "cancelled"
-	JobStateFailed JobState = "failed"
-	JobStateUnknown JobState = "unknown"
+	JobStateCompleted   JobState = "completed"
+	JobStateCancelled   JobState = "cancelled"
+	JobStateFailed      JobState = "failed"
+	JobStateTimeout     JobState = "timeout"
+	JobStateOutOfMemory JobState = "out_of_memory"
+	JobStateNodeFail    JobState = "node_fail"
+	JobStatePreempted   JobState = "preempted"
+	JobStateBootFail    JobState = "boot_fail"
+	JobStateDeadline    JobState = "deadline"
+	JobStateRunning     JobState = "running" // only billed in prorate mode
+	JobStateUnknown     JobState = "unknown"
 )
+
+// JobStates lists every JobState in a stable order for reporting.
+func JobStates() []JobState {
+	return []JobState{
+		JobStateCompleted,
+		JobStateCancelled,
+		JobStateFailed,
+		JobStateTimeout,
+		JobStateOutOfMemory,
+		JobStateNodeFail,
+		JobStatePreempted,
+		JobStateBootFail,
+		JobStateDeadline,
+		JobStateRunning,
+		JobStateUnknown,
+	}
+}
+
+// slurmJobStates maps the states to the name sacct reports and the short
+// name --state accepts.
+var slurmJobStates = map[JobState][2]string{
+	JobStateCompleted:   {"COMPLETED", "CD"},
+	JobStateCancelled:   {"CANCELLED", "CA"},
+	JobStateFailed:      {"FAILED", "F"},
+	JobStateTimeout:     {"TIMEOUT", "TO"},
+	JobStateOutOfMemory: {"OUT_OF_MEMORY", "OOM"},
+	JobStateNodeFail:    {"NODE_FAIL", "NF"},
+	JobStatePreempted:   {"PREEMPTED", "PR"},
+	JobStateBootFail:    {"BOOT_FAIL", "BF"},
+	JobStateDeadline:    {"DEADLINE", "DL"},
+	JobStateRunning:     {"RUNNING", "R"},
+}
+
+// SlurmName is the state as sacct reports it, e.g. OUT_OF_MEMORY.
+func (s JobState) SlurmName() string {
+	return slurmJobStates[s][0]
+}
+
+// SlurmCode is the short state name sacct --state accepts, e.g. OOM.
+func (s JobState) SlurmCode() string {
+	return slurmJobStates[s][1]
+}
+
+// TerminalJobStates lists the states a finished job can end in, which is
+// every JobState except running and unknown.
+func TerminalJobStates() []JobState {
+	states := []JobState{}
+	for _, s := range JobStates() {
+		if s != JobStateRunning && s != JobStateUnknown {
+			states = append(states, s)
+		}
+	}
+	return states
+}
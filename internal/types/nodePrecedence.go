@@ -0,0 +1,13 @@
+package types
+
+// How a node that is in both open-use and condo partitions is counted when
+// weighting jobs.
+const (
+	// NodePrecedenceOpenUse counts the node as open-use
+	NodePrecedenceOpenUse = "openuse"
+	// NodePrecedenceCondo counts the node as condo
+	NodePrecedenceCondo = "condo"
+	// NodePrecedenceShared splits the node by the share of its partitions
+	// that are open-use
+	NodePrecedenceShared = "shared"
+)
@@ -0,0 +1,25 @@
+package types
+
+// Which of a job's compute hours a service unit rule charges for.
+const (
+	ServiceUnitHoursOpenUse = "openuse"
+	ServiceUnitHoursCondo   = "condo"
+	ServiceUnitHoursTotal   = "total"
+)
+
+// ServiceUnitRule is one row of the service unit rate table. Empty match
+// fields match anything, and the first rule that matches a job is used.
+type ServiceUnitRule struct {
+	Category   JobCategory `yaml:"category"`
+	Partitions []string    `yaml:"partitions"`
+	QOS        []string    `yaml:"qos"`
+	// GPU matches on whether the job allocated any GPUs when set
+	GPU *bool `yaml:"gpu"`
+
+	// Hours is openuse, condo or total, defaulting to openuse
+	Hours   string  `yaml:"hours"`
+	CPURate float64 `yaml:"cpu_rate"`
+	GPURate float64 `yaml:"gpu_rate"`
+	// GPUModelRates overrides GPURate for the GPU models listed, e.g. h100
+	GPUModelRates map[string]float64 `yaml:"gpu_model_rates"`
+}